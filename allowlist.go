@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// OperationNotAllowedError is returned when a request is refused because
+// its document isn't one of those given to WithAllowedOperations or
+// WithAllowedOperationHashes. Nothing is sent to the server.
+type OperationNotAllowedError struct {
+	// Hash is the SHA-256 hash of the refused document, as given to
+	// WithAllowedOperationHashes.
+	Hash string
+}
+
+func (e *OperationNotAllowedError) Error() string {
+	return "graphql: operation not allowed: " + e.Hash
+}
+
+// WithAllowedOperations restricts the client to sending the given
+// documents, refusing any other request with an OperationNotAllowedError.
+// Documents are compared ignoring insignificant whitespace, commas and
+// comments. It may be combined with WithAllowedOperationHashes, and used
+// more than once, to allow more documents.
+//
+// The allowlist applies to every request the client makes, including
+// the query sent by Ping.
+func WithAllowedOperations(docs ...string) ClientOption {
+	return func(client *Client) {
+		for _, doc := range docs {
+			client.allowOperation(hashDocument(normalizeDocument(doc)))
+		}
+	}
+}
+
+// WithAllowedOperationHashes is like WithAllowedOperations but takes the
+// hex encoded SHA-256 hashes of the allowed documents, such as a
+// persisted query manifest provides. A hash may be of the document
+// exactly as sent, or with insignificant characters removed.
+func WithAllowedOperationHashes(hashes ...string) ClientOption {
+	return func(client *Client) {
+		for _, hash := range hashes {
+			client.allowOperation(strings.ToLower(hash))
+		}
+	}
+}
+
+func (c *Client) allowOperation(hash string) {
+	// copy the allowlist so a derived client doesn't change its parent's
+	allowed := make(map[string]bool, len(c.allowedOperations)+1)
+	for h := range c.allowedOperations {
+		allowed[h] = true
+	}
+	allowed[hash] = true
+	c.allowedOperations = allowed
+}
+
+// checkAllowed returns an OperationNotAllowedError if an allowlist has
+// been set and the document q is not on it.
+func (c *Client) checkAllowed(q string) error {
+	if c.allowedOperations == nil {
+		return nil
+	}
+	hash := hashDocument(q)
+	if c.allowedOperations[hash] || c.allowedOperations[hashDocument(normalizeDocument(q))] {
+		return nil
+	}
+	return &OperationNotAllowedError{Hash: hash}
+}
+
+// normalizeDocument renders q without insignificant characters, so that
+// documents differing only in formatting compare equal. Documents that
+// can't be lexed are returned as they are.
+func normalizeDocument(q string) string {
+	tokens, err := lex(q)
+	if err != nil {
+		return q
+	}
+	return joinTokens(tokens)
+}
+
+// hashDocument returns the hex encoded SHA-256 hash of q.
+func hashDocument(q string) string {
+	sum := sha256.Sum256([]byte(q))
+	return hex.EncodeToString(sum[:])
+}
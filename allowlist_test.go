@@ -0,0 +1,49 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestAllowedOperations(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{"item":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := NewClient(srv.URL,
+		WithAllowedOperations("query ($id: ID!) { item(id: $id) }"),
+		WithAllowedOperationHashes(hashDocument("{ other }")),
+	)
+
+	_, err := client.Run(ctx, NewRequest("query($id:ID!){\n  item(id: $id) # the item\n}"), nil)
+	is.NoErr(err)
+	_, err = client.Run(ctx, NewRequest("{ other }"), nil)
+	is.NoErr(err)
+	is.Equal(calls, 2)
+
+	_, err = client.Run(ctx, NewRequest("{ secrets }"), nil)
+	var notAllowed *OperationNotAllowedError
+	is.True(errors.As(err, &notAllowed))
+	is.Equal(notAllowed.Hash, hashDocument("{ secrets }"))
+	_, err = client.RunMerged(ctx, []*Request{NewRequest("{ other }"), NewRequest("{ secrets }")}, make([]interface{}, 2))
+	is.True(errors.As(err, &notAllowed))
+	is.Equal(calls, 2) // nothing sent
+
+	derived := client.With(WithAllowedOperations("{ secrets }"))
+	_, err = derived.Run(ctx, NewRequest("{ secrets }"), nil)
+	is.NoErr(err)
+	_, err = client.Run(ctx, NewRequest("{ secrets }"), nil)
+	is.True(err != nil) // the parent's allowlist is unchanged
+}
@@ -0,0 +1,200 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// defaultMaxURLLength is the longest URL sent with GET unless
+// WithMaxURLLength says otherwise. Many proxies and CDNs reject or
+// silently truncate longer ones.
+const defaultMaxURLLength = 8 << 10
+
+// WithPersistedQueries enables automatic persisted queries (APQ).
+//
+// Each request is first sent with only the SHA-256 hash of its query.
+// If the server doesn't know the hash it replies with
+// PersistedQueryNotFound, and the request is sent again with the full
+// query so the server can store it. Once stored, later requests save
+// sending the query at all.
+//
+// With the GET transport the hash-only request is sent as a GET, which
+// caches and CDNs can store, and the full query falls back to a POST.
+func WithPersistedQueries() ClientOption {
+	return func(client *Client) {
+		client.persistedQueries = true
+	}
+}
+
+// WithPersistedQueryRegistry enables automatic persisted queries, as
+// WithPersistedQueries does, and records in reg the hashes the server
+// has acknowledged. A query whose hash is known is sent with the hash
+// alone, as before, but one that isn't is sent in full along with its
+// hash at once, saving the round trip of a hash-only request the server
+// would reject. A known hash the server has since forgotten is dropped
+// and the query sent in full again.
+//
+// A registry may be shared by clients, and is kept per endpoint. A
+// short-lived process can save its Hashes when it exits and Add them
+// back when it starts, so that it doesn't send its queries in full
+// every time.
+func WithPersistedQueryRegistry(reg *PersistedQueryRegistry) ClientOption {
+	return func(client *Client) {
+		client.persistedQueries = true
+		client.persistedRegistry = reg
+	}
+}
+
+// PersistedQueryRegistry records, per endpoint, the hashes of the
+// persisted queries a server is known to have stored. It is safe for
+// concurrent use.
+type PersistedQueryRegistry struct {
+	mu     sync.Mutex
+	hashes map[string]map[string]struct{}
+}
+
+// NewPersistedQueryRegistry makes an empty PersistedQueryRegistry.
+func NewPersistedQueryRegistry() *PersistedQueryRegistry {
+	return &PersistedQueryRegistry{hashes: make(map[string]map[string]struct{})}
+}
+
+// Add records that the server at endpoint has stored the queries with
+// the given hashes, such as ones saved by an earlier process.
+func (r *PersistedQueryRegistry) Add(endpoint string, hashes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	known := r.hashes[endpoint]
+	if known == nil {
+		known = make(map[string]struct{}, len(hashes))
+		r.hashes[endpoint] = known
+	}
+	for _, hash := range hashes {
+		known[hash] = struct{}{}
+	}
+}
+
+// Remove forgets that the server at endpoint has stored the query with
+// the given hash.
+func (r *PersistedQueryRegistry) Remove(endpoint, hash string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.hashes[endpoint], hash)
+}
+
+// Known reports whether the server at endpoint is known to have stored
+// the query with the given hash.
+func (r *PersistedQueryRegistry) Known(endpoint, hash string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.hashes[endpoint][hash]
+	return ok
+}
+
+// Hashes returns, in order, the hashes known for endpoint.
+func (r *PersistedQueryRegistry) Hashes(endpoint string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hashes := make([]string, 0, len(r.hashes[endpoint]))
+	for hash := range r.hashes[endpoint] {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// Endpoints returns, in order, the endpoints with known hashes.
+func (r *PersistedQueryRegistry) Endpoints() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	endpoints := make([]string, 0, len(r.hashes))
+	for endpoint, hashes := range r.hashes {
+		if len(hashes) > 0 {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+// WithMaxURLLength sets the longest URL the client will send with GET,
+// measured once the query and variables are encoded into it. Requests
+// that would need a longer URL are sent with POST instead. The default
+// is 8KB; zero or less removes the limit.
+func WithMaxURLLength(n int) ClientOption {
+	return func(client *Client) {
+		client.maxURLLength = n
+	}
+}
+
+// persistedQuery describes the persisted query extension sent with a
+// request.
+type persistedQuery struct {
+	hash string
+	// withQuery is set when the full query is sent along with the hash,
+	// for the server to store.
+	withQuery bool
+}
+
+// writeExtensions writes the extensions object for pq to buf.
+func (pq *persistedQuery) writeExtensions(buf *bytes.Buffer) {
+	buf.WriteString(`{"persistedQuery":{"sha256Hash":`)
+	writeJSONString(buf, pq.hash)
+	buf.WriteString(`,"version":1}}`)
+}
+
+// runPersisted runs the request as an automatic persisted query, sending
+// the hash alone and falling back to the full query if the server needs
+// it. With a registry, queries the server isn't known to have are sent
+// in full at once.
+func (c *Client) runPersisted(ctx context.Context, req *Request, transport Transport, gr *graphResponse) (*http.Response, error) {
+	pq := &persistedQuery{hash: hashDocument(req.q)}
+	reg := c.persistedRegistry
+	if reg != nil && !reg.Known(c.endpoint, pq.hash) {
+		return c.runFullPersisted(ctx, req, pq.hash, gr)
+	}
+	send := c.runWithJSON
+	if transport == TransportGET {
+		send = c.runWithGET
+	}
+	probe := &graphResponse{Data: gr.Data, bodies: gr.bodies}
+	res, err := send(ctx, req, pq, probe)
+	if err != nil || !persistedQueryNotFound(probe.Errors) {
+		gr.Errors, gr.body, gr.rawBody = probe.Errors, probe.body, probe.rawBody
+		return res, err
+	}
+	c.logf("persisted query %s not found, sending full query", pq.hash)
+	if reg != nil {
+		reg.Remove(c.endpoint, pq.hash)
+	}
+	return c.runFullPersisted(ctx, req, pq.hash, gr)
+}
+
+// runFullPersisted sends the full query along with its hash for the
+// server to store, recording the hash in the client's registry if the
+// server accepts it.
+func (c *Client) runFullPersisted(ctx context.Context, req *Request, hash string, gr *graphResponse) (*http.Response, error) {
+	res, err := c.runWithJSON(ctx, req, &persistedQuery{hash: hash, withQuery: true}, gr)
+	if err == nil && c.persistedRegistry != nil && !persistedQueryNotFound(gr.Errors) {
+		c.persistedRegistry.Add(c.endpoint, hash)
+	}
+	return res, err
+}
+
+// persistedQueryNotFound reports whether errs say the server needs the
+// full query, either because it hasn't stored the hash yet or because it
+// doesn't support persisted queries at all.
+func persistedQueryNotFound(errs []GraphQLError) bool {
+	for _, err := range errs {
+		code, _ := err.Extensions["code"].(string)
+		switch {
+		case err.Message == "PersistedQueryNotFound", code == "PERSISTED_QUERY_NOT_FOUND":
+			return true
+		case err.Message == "PersistedQueryNotSupported", code == "PERSISTED_QUERY_NOT_SUPPORTED":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestPersistedQueries(t *testing.T) {
+	is := is.New(t)
+
+	const query = "query { item }"
+	const hash = "56abe5c9337a6e4e1bf175b41079ca220567c649eb2ae6d06135fc12d79315e5"
+	stored := make(map[string]string)
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query      string
+			Extensions struct {
+				PersistedQuery struct {
+					Version    int
+					SHA256Hash string
+				}
+			}
+		}
+		if r.Method == http.MethodGet {
+			body.Query = r.URL.Query().Get("query")
+			is.NoErr(json.Unmarshal([]byte(r.URL.Query().Get("extensions")), &body.Extensions))
+		} else {
+			is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		}
+		pq := body.Extensions.PersistedQuery
+		is.Equal(pq.Version, 1)
+		is.Equal(pq.SHA256Hash, hash)
+		calls = append(calls, r.Method+" "+body.Query)
+		if body.Query != "" {
+			stored[pq.SHA256Hash] = body.Query
+		}
+		if _, ok := stored[pq.SHA256Hash]; !ok {
+			io.WriteString(w, `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`)
+			return
+		}
+		io.WriteString(w, `{"data":{"item":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	for _, transport := range []Transport{TransportJSON, TransportGET} {
+		calls = nil
+		stored = make(map[string]string)
+		client := NewClient(srv.URL, WithPersistedQueries(), WithTransport(transport))
+		var resp struct {
+			Item string
+		}
+		_, err := client.Run(ctx, NewRequest(query), &resp)
+		is.NoErr(err)
+		is.Equal(resp.Item, "some data")
+		_, err = client.Run(ctx, NewRequest(query), &resp)
+		is.NoErr(err)
+
+		method := http.MethodPost
+		if transport == TransportGET {
+			method = http.MethodGet
+		}
+		is.Equal(calls, []string{
+			method + " ",    // hash only
+			"POST " + query, // not found, so the full query
+			method + " ",    // stored now
+		})
+	}
+}
+
+func TestPersistedQueryRegistry(t *testing.T) {
+	is := is.New(t)
+
+	const query = "query { item }"
+	const hash = "56abe5c9337a6e4e1bf175b41079ca220567c649eb2ae6d06135fc12d79315e5"
+	stored := make(map[string]bool)
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query      string
+			Extensions struct {
+				PersistedQuery struct {
+					SHA256Hash string
+				}
+			}
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		calls = append(calls, body.Query)
+		if body.Query != "" {
+			stored[body.Extensions.PersistedQuery.SHA256Hash] = true
+		}
+		if !stored[body.Extensions.PersistedQuery.SHA256Hash] {
+			io.WriteString(w, `{"errors":[{"message":"PersistedQueryNotFound"}]}`)
+			return
+		}
+		io.WriteString(w, `{"data":{"item":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	reg := NewPersistedQueryRegistry()
+	client := NewClient(srv.URL, WithPersistedQueryRegistry(reg))
+
+	// unknown, so the full query is sent at once
+	_, err := client.Run(ctx, NewRequest(query), nil)
+	is.NoErr(err)
+	is.Equal(calls, []string{query})
+	is.Equal(reg.Hashes(srv.URL), []string{hash})
+	is.Equal(reg.Endpoints(), []string{srv.URL})
+
+	// known, so the hash alone
+	calls = nil
+	_, err = client.Run(ctx, NewRequest(query), nil)
+	is.NoErr(err)
+	is.Equal(calls, []string{""})
+
+	// a new process seeded from the last one's hashes
+	seeded := NewPersistedQueryRegistry()
+	seeded.Add(srv.URL, reg.Hashes(srv.URL)...)
+	calls = nil
+	_, err = NewClient(srv.URL, WithPersistedQueryRegistry(seeded)).Run(ctx, NewRequest(query), nil)
+	is.NoErr(err)
+	is.Equal(calls, []string{""})
+
+	// the server forgets the hash
+	delete(stored, hash)
+	calls = nil
+	_, err = client.Run(ctx, NewRequest(query), nil)
+	is.NoErr(err)
+	is.Equal(calls, []string{"", query})
+	is.True(reg.Known(srv.URL, hash))
+
+	// hashes are kept per endpoint
+	is.True(!reg.Known("https://other.example/graphql", hash))
+	reg.Remove(srv.URL, hash)
+	is.Equal(reg.Hashes(srv.URL), []string{})
+	is.Equal(reg.Endpoints(), []string{})
+}
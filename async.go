@@ -0,0 +1,34 @@
+package graphql
+
+import "context"
+
+// Future is the pending outcome of a request started with RunAsync.
+type Future struct {
+	done   chan struct{}
+	result Result
+}
+
+// RunAsync starts executing the request in the background and returns a
+// Future for its Result, leaving the caller free to do other work in
+// the meantime. Canceling ctx cancels the request.
+func (c *Client) RunAsync(ctx context.Context, req *Request) *Future {
+	f := &Future{
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(f.done)
+		f.result.Response, f.result.Err = c.Run(ctx, req, &f.result.Data)
+	}()
+	return f
+}
+
+// Done returns a channel that is closed once the request has finished.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the request has finished and returns its Result.
+func (f *Future) Wait() Result {
+	<-f.done
+	return f.result
+}
@@ -0,0 +1,39 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRunAsync(t *testing.T) {
+	is := is.New(t)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL)
+
+	f := client.RunAsync(ctx, NewRequest("query {}"))
+	select {
+	case <-f.Done():
+		t.Fatal("request finished before the server responded")
+	default:
+	}
+	close(release)
+	result := f.Wait()
+	is.NoErr(result.Err)
+	is.Equal(result.Response.StatusCode, http.StatusOK)
+	is.Equal(string(result.Data), `{"value":"some data"}`)
+}
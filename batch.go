@@ -0,0 +1,94 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// defaultRunAllConcurrency is the number of requests RunAll runs at once
+// unless WithRunAllConcurrency says otherwise.
+const defaultRunAllConcurrency = 4
+
+// Result is the outcome of one of the requests run by RunAll.
+type Result struct {
+	// Response is the response, if one was received.
+	Response *Response
+	// Data is the undecoded data field of the response.
+	Data json.RawMessage
+	// Err is the error returned by the request, if any.
+	Err error
+}
+
+// RunAll executes the requests concurrently, running at most
+// WithRunAllConcurrency of them at a time, and returns a Result for each
+// request in the order they were given. Requests are started in
+// Priority order.
+//
+// By default every request is run and the returned error joins the
+// errors of all the requests that failed, in request order, with
+// errors.Join; each is also in the Err of its Result. With the
+// RunAllFailFast option the first failure cancels the requests still
+// running or waiting to run, and is the error returned.
+func (c *Client) RunAll(ctx context.Context, reqs ...*Request) ([]Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	concurrency := c.runAllConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultRunAllConcurrency
+	}
+	results := make([]Result, len(reqs))
+	sem := make(chan struct{}, concurrency)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for _, i := range byPriority(reqs) {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := &results[i]
+			result.Response, result.Err = c.Run(ctx, reqs[i], &result.Data)
+			if result.Err != nil && c.runAllFailFast {
+				once.Do(func() {
+					firstErr = result.Err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if c.runAllFailFast {
+		return results, firstErr
+	}
+	errs := make([]error, len(results))
+	for i, result := range results {
+		errs[i] = result.Err
+	}
+	return results, errors.Join(errs...)
+}
+
+// WithRunAllConcurrency sets the maximum number of requests RunAll will
+// run at the same time.
+func WithRunAllConcurrency(n int) ClientOption {
+	return func(client *Client) {
+		client.runAllConcurrency = n
+	}
+}
+
+// RunAllFailFast makes RunAll stop at the first failed request instead
+// of running every request.
+func RunAllFailFast() ClientOption {
+	return func(client *Client) {
+		client.runAllFailFast = true
+	}
+}
@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRunAll(t *testing.T) {
+	is := is.New(t)
+
+	var inflight, maxInflight int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInflight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInflight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		var body struct {
+			Variables map[string]interface{}
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		if n, _ := body.Variables["n"].(string); strings.HasPrefix(n, "bad") {
+			fmt.Fprintf(w, `{"errors":[{"message":"%s request"}]}`, n)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": body.Variables})
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithRunAllConcurrency(2))
+
+	var reqs []*Request
+	for _, n := range []string{"a", "b", "bad", "c", "d", "bad again"} {
+		req := NewRequest("query {}")
+		req.Var("n", n)
+		reqs = append(reqs, req)
+	}
+	results, err := client.RunAll(ctx, reqs...)
+	is.Equal(err.Error(), "graphql: bad request\ngraphql: bad again request") // every failure
+	is.Equal(len(results), 6)
+	is.Equal(string(results[0].Data), `{"n":"a"}`)
+	is.Equal(string(results[4].Data), `{"n":"d"}`)
+	is.True(errors.Is(err, results[2].Err))
+	is.True(errors.Is(err, results[5].Err))
+	is.NoErr(results[3].Err)
+	is.True(atomic.LoadInt32(&maxInflight) <= 2)
+}
+
+func TestRunAllFailFast(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"errors":[{"message":"bad request"}]}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithRunAllConcurrency(1), RunAllFailFast())
+
+	results, err := client.RunAll(ctx, NewRequest("query {}"), NewRequest("query {}"))
+	is.Equal(err.Error(), "graphql: bad request")
+	is.Equal(results[0].Err, err)
+	is.Equal(results[1].Err, context.Canceled)
+}
@@ -0,0 +1,157 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// writeJSONBody writes the JSON body of req to buf, as a persisted query
+// if pq is not nil. The body is written directly, rather than by
+// marshaling a struct, since it's built for every request.
+func writeJSONBody(buf *bytes.Buffer, req *Request, pq *persistedQuery) error {
+	buf.WriteByte('{')
+	if pq == nil || pq.withQuery {
+		buf.WriteString(`"query":`)
+		writeJSONString(buf, req.q)
+		buf.WriteByte(',')
+	}
+	if req.operation != "" {
+		buf.WriteString(`"operationName":`)
+		writeJSONString(buf, req.operation)
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"variables":`)
+	if err := writeJSONVariables(buf, req.vars); err != nil {
+		return err
+	}
+	if pq != nil {
+		buf.WriteString(`,"extensions":`)
+		pq.writeExtensions(buf)
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+// writeJSONVariables encodes vars to buf as a JSON object, with its keys
+// sorted as json.Marshal sorts them.
+func writeJSONVariables(buf *bytes.Buffer, vars map[string]interface{}) error {
+	if vars == nil {
+		buf.WriteString("null")
+		return nil
+	}
+	// most requests have a handful of variables, which fit on the stack
+	keys := make([]string, 0, 8)
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(buf, key)
+		buf.WriteByte(':')
+		if err := writeJSONValue(buf, vars[key]); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeJSONValue encodes v to buf, as json.Marshal would. The common
+// scalar types are written directly, and anything else with an encoder.
+func writeJSONValue(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+		return nil
+	case string:
+		writeJSONString(buf, v)
+		return nil
+	case ID:
+		writeJSONString(buf, string(v))
+		return nil
+	case bool:
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), v))
+		return nil
+	case int:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(v), 10))
+		return nil
+	case int64:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), v, 10))
+		return nil
+	case int32:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(v), 10))
+		return nil
+	}
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	// drop the newline the encoder ends with
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJSONString writes s to buf as a JSON string, escaped the way
+// encoding/json escapes it: invalid UTF-8 is replaced, and characters
+// that have special meaning in HTML are escaped.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			case '\b':
+				buf.WriteString(`\b`)
+			case '\f':
+				buf.WriteString(`\f`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[c>>4])
+				buf.WriteByte(hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf.WriteString(s[start:i])
+			buf.WriteString("\ufffd")
+		case r == '\u2028' || r == '\u2029':
+			// valid JSON, but not valid JavaScript
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hexDigits[r&0xf])
+		default:
+			i += size
+			continue
+		}
+		i += size
+		start = i
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
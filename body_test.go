@@ -0,0 +1,105 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWriteJSONString(t *testing.T) {
+	is := is.New(t)
+
+	for _, s := range []string{
+		"",
+		"query { a }",
+		`"quoted" \ slash`,
+		"lines\n\r\ttabs\b\f\x00\x1f",
+		"<html> & friends",
+		"unicode: héllo 世界   ",
+		"invalid \xff utf-8",
+	} {
+		var buf bytes.Buffer
+		writeJSONString(&buf, s)
+		want, err := json.Marshal(s)
+		is.NoErr(err)
+		is.Equal(buf.String(), string(want))
+	}
+}
+
+func TestWriteJSONBody(t *testing.T) {
+	is := is.New(t)
+
+	req := NewRequest("query ($id: ID!) { node(id: $id) { id } }")
+	req.Var("id", "<1>")
+	var buf bytes.Buffer
+	is.NoErr(writeJSONBody(&buf, req, nil))
+	is.Equal(buf.String(), `{"query":"query ($id: ID!) { node(id: $id) { id } }","variables":{"id":"\u003c1\u003e"}}`+"\n")
+
+	buf.Reset()
+	is.NoErr(writeJSONBody(&buf, NewRequest("{ a }"), &persistedQuery{hash: "abc"}))
+	is.Equal(buf.String(), `{"variables":null,"extensions":{"persistedQuery":{"sha256Hash":"abc","version":1}}}`+"\n")
+
+	buf.Reset()
+	is.NoErr(writeJSONBody(&buf, NewRequest("{ a }"), &persistedQuery{hash: "abc", withQuery: true}))
+	is.Equal(buf.String(), `{"query":"{ a }","variables":null,"extensions":{"persistedQuery":{"sha256Hash":"abc","version":1}}}`+"\n")
+
+	vars := map[string]interface{}{
+		"b": true, "a": 1, "c": int64(-2), "d": int32(3), "e": nil, "f": 1.5e21,
+		"g": []string{"x"}, "h": map[string]int{"z": 1, "y": 2}, "i": json.RawMessage(`{ "raw": 1 }`), "j": ID("7"),
+	}
+	buf.Reset()
+	is.NoErr(writeJSONVariables(&buf, vars))
+	want, err := json.Marshal(vars)
+	is.NoErr(err)
+	is.Equal(buf.String(), string(want))
+
+	bad := NewRequest("{ a }")
+	bad.Var("f", func() {})
+	is.True(writeJSONBody(&buf, bad, nil) != nil)
+}
+
+// TestWriteJSONBodyAllocs guards the allocations made encoding a request
+// body, which is done for every request.
+func TestWriteJSONBodyAllocs(t *testing.T) {
+	is := is.New(t)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	noVars := NewRequest("query Q { a b c }")
+	pq := &persistedQuery{hash: hashDocument(noVars.q)}
+	withVars := NewRequest("query Q($id: ID!) { node(id: $id) { id } }")
+	withVars.Var("id", "1")
+	for _, test := range []struct {
+		req    *Request
+		pq     *persistedQuery
+		allocs float64
+	}{
+		{req: noVars, allocs: 0},
+		{req: noVars, pq: pq, allocs: 0},
+		{req: withVars, allocs: 0},
+	} {
+		allocs := testing.AllocsPerRun(100, func() {
+			buf.Reset()
+			writeJSONBody(buf, test.req, test.pq)
+		})
+		t.Logf("%q: %v allocs", test.req.q, allocs)
+		is.True(allocs <= test.allocs)
+	}
+}
+
+func BenchmarkWriteJSONBody(b *testing.B) {
+	req := NewRequest("query Q($id: ID!, $first: Int) { node(id: $id) { id items(first: $first) { name } } }")
+	req.Var("id", "1")
+	req.Var("first", 10)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := writeJSONBody(buf, req, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBufferSize is the capacity above which buffers aren't
+// returned to the pool, so that one huge request doesn't pin its memory
+// for the life of the process.
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns buf to the pool. It must not be used afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// pooledBody is a request body held in a pooled buffer. The transport
+// may still be writing a body after Do returns, such as when the server
+// responds before reading all of it, so the buffer is only returned to
+// the pool once the caller and every reader given to the transport are
+// done with it.
+type pooledBody struct {
+	buf  *bytes.Buffer
+	refs int32
+}
+
+// newPooledBody makes a body of buf, from getBuffer. The caller holds a
+// reference to it, which it gives up with release.
+func newPooledBody(buf *bytes.Buffer) *pooledBody {
+	return &pooledBody{buf: buf, refs: 1}
+}
+
+// newRequest makes an HTTP request with the body. Its GetBody lets the
+// transport send the body again, for redirects and requests retried on
+// a new connection, without copying it.
+func (b *pooledBody) newRequest(method, url string) (*http.Request, error) {
+	r, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.ContentLength = int64(b.buf.Len())
+	r.Body = b.reader()
+	r.GetBody = func() (io.ReadCloser, error) {
+		return b.reader(), nil
+	}
+	return r, nil
+}
+
+// reader returns a reader of the body, which must be closed.
+func (b *pooledBody) reader() io.ReadCloser {
+	atomic.AddInt32(&b.refs, 1)
+	return &pooledBodyReader{Reader: bytes.NewReader(b.buf.Bytes()), body: b}
+}
+
+func (b *pooledBody) release() {
+	if atomic.AddInt32(&b.refs, -1) == 0 {
+		putBuffer(b.buf)
+	}
+}
+
+type pooledBodyReader struct {
+	*bytes.Reader
+	body *pooledBody
+	once sync.Once
+}
+
+func (r *pooledBodyReader) Close() error {
+	r.once.Do(r.body.release)
+	return nil
+}
+
+// requestBodies keeps the JSON bodies built for a request over all of
+// its attempts, so that retries send the same bytes again rather than
+// encoding the request from scratch. A persisted query can need two: the
+// hash alone, then the full query. A nil *requestBodies builds a new
+// body each time.
+type requestBodies struct {
+	bodies map[jsonBodyKey]*pooledBody
+}
+
+type jsonBodyKey struct {
+	persisted, withQuery bool
+}
+
+// json returns the JSON body of req, sent as a persisted query if pq is
+// not nil, and a function to call once the attempt is done with it.
+func (b *requestBodies) json(req *Request, pq *persistedQuery) (*pooledBody, func(), error) {
+	key := jsonBodyKey{persisted: pq != nil, withQuery: pq != nil && pq.withQuery}
+	if b != nil {
+		if body, ok := b.bodies[key]; ok {
+			return body, func() {}, nil
+		}
+	}
+	buf := getBuffer()
+	if err := writeJSONBody(buf, req, pq); err != nil {
+		putBuffer(buf)
+		return nil, nil, err
+	}
+	body := newPooledBody(buf)
+	if b == nil {
+		return body, body.release, nil
+	}
+	if b.bodies == nil {
+		b.bodies = make(map[jsonBodyKey]*pooledBody)
+	}
+	b.bodies[key] = body
+	return body, func() {}, nil
+}
+
+// release gives up the bodies once the request is done.
+func (b *requestBodies) release() {
+	for _, body := range b.bodies {
+		body.release()
+	}
+}
@@ -0,0 +1,105 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestPooledBody(t *testing.T) {
+	is := is.New(t)
+
+	buf := getBuffer()
+	buf.WriteString(`{"query":"{ a }"}`)
+	body := newPooledBody(buf)
+	r, err := body.newRequest(http.MethodPost, "https://example.com/graphql")
+	is.NoErr(err)
+	is.Equal(r.ContentLength, int64(buf.Len()))
+	replay, err := r.GetBody()
+	is.NoErr(err)
+	b, err := io.ReadAll(replay)
+	is.NoErr(err)
+	is.Equal(string(b), `{"query":"{ a }"}`)
+
+	body.release()
+	r.Body.Close()
+	r.Body.Close() // closing twice releases once
+	is.Equal(body.refs, int32(1))
+	replay.Close()
+	is.Equal(body.refs, int32(0))
+}
+
+func TestPooledBuffersContentLength(t *testing.T) {
+	is := is.New(t)
+
+	var lengths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		is.NoErr(err)
+		is.Equal(r.Header.Get("Content-Length"), strconv.Itoa(len(b)))
+		lengths = append(lengths, r.Header.Get("Content-Length"))
+		io.WriteString(w, `{"data":{"value":"ok"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL)
+	for _, multipart := range []bool{false, true} {
+		req := NewRequest("{ value }")
+		req.Var("n", 1)
+		if multipart {
+			req.Transport(TransportMultipart)
+		}
+		var resp struct{ Value string }
+		_, err := client.Run(ctx, req, &resp)
+		is.NoErr(err)
+		is.Equal(resp.Value, "ok")
+	}
+	is.Equal(len(lengths), 2) // sent with a length rather than chunked
+}
+
+// countingMarshaler counts the times it is encoded.
+type countingMarshaler struct {
+	calls *int
+}
+
+func (m countingMarshaler) MarshalJSON() ([]byte, error) {
+	*m.calls++
+	return []byte(`"value"`), nil
+}
+
+func TestRetriesReuseBody(t *testing.T) {
+	is := is.New(t)
+
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		is.NoErr(err)
+		bodies = append(bodies, string(b))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithRetry(3, func(int) time.Duration { return time.Millisecond }))
+	var calls int
+	req := NewRequest("query ($v: String) { a(v: $v) }")
+	req.Var("v", countingMarshaler{calls: &calls})
+	_, err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(len(bodies), 3)
+	is.Equal(bodies[0], bodies[2])
+	is.Equal(calls, 1) // encoded once for every attempt
+}
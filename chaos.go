@@ -0,0 +1,166 @@
+package graphql
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Chaos injects faults into requests, so that services built on the
+// client can test how their retries, timeouts and circuit breakers cope
+// with a misbehaving server. Probabilities run from 0, never, to 1,
+// always.
+//
+// Each request gets the added latency with LatencyProbability, and then
+// at most one of the other faults, chosen with their probabilities. The
+// faults are drawn from a random source seeded with Seed, so a test
+// that sends its requests in the same order meets the same faults every
+// time.
+//
+// A Chaos may be shared by clients and used concurrently; its fields
+// must not be changed once it is in use.
+type Chaos struct {
+	// Latency is added before a request is sent.
+	Latency            time.Duration
+	LatencyProbability float64
+	// ResetProbability is the chance of failing a request, without
+	// sending it, as if the connection had been reset.
+	ResetProbability float64
+	// ErrorProbability is the chance of answering a request, without
+	// sending it, with ErrorStatus, or 503 if that is zero.
+	ErrorProbability float64
+	ErrorStatus      int
+	// MalformedProbability is the chance of sending a request but
+	// cutting the body of its response short, so it can't be decoded.
+	MalformedProbability float64
+	Seed                 int64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// WithChaos makes the client inject the faults ch describes into every
+// attempt it makes. It is meant for tests, never for production.
+func WithChaos(ch *Chaos) ClientOption {
+	return func(client *Client) {
+		client.chaos = ch
+	}
+}
+
+// RoundTripper returns an http.RoundTripper that injects faults into the
+// requests it sends with base, or with http.DefaultTransport if base is
+// nil, for use with clients other than this package's.
+func (ch *Chaos) RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &chaosTransport{chaos: ch, base: base}
+}
+
+type chaosTransport struct {
+	chaos *Chaos
+	base  http.RoundTripper
+}
+
+func (t *chaosTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return t.chaos.roundTrip(r, t.base.RoundTrip)
+}
+
+// chaosFault is a fault injected into a request.
+type chaosFault int
+
+const (
+	chaosNone chaosFault = iota
+	chaosReset
+	chaosError
+	chaosMalformed
+)
+
+// draw decides the faults for the next request.
+func (ch *Chaos) draw() (delay bool, fault chaosFault) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.rand == nil {
+		ch.rand = rand.New(rand.NewSource(ch.Seed))
+	}
+	delay = ch.rand.Float64() < ch.LatencyProbability
+	roll := ch.rand.Float64()
+	switch {
+	case roll < ch.ResetProbability:
+		fault = chaosReset
+	case roll < ch.ResetProbability+ch.ErrorProbability:
+		fault = chaosError
+	case roll < ch.ResetProbability+ch.ErrorProbability+ch.MalformedProbability:
+		fault = chaosMalformed
+	}
+	return delay, fault
+}
+
+// roundTrip sends r with send, injecting the faults drawn for it.
+func (ch *Chaos) roundTrip(r *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	delay, fault := ch.draw()
+	if delay && ch.Latency > 0 {
+		timer := time.NewTimer(ch.Latency)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return nil, r.Context().Err()
+		case <-timer.C:
+		}
+	}
+	switch fault {
+	case chaosReset:
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	case chaosError:
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		status := ch.ErrorStatus
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		body := "graphql: error injected by Chaos"
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+			Body:          io.NopCloser(bytes.NewReader([]byte(body))),
+			ContentLength: int64(len(body)),
+			Request:       r,
+		}, nil
+	}
+	res, err := send(r)
+	if err != nil || fault != chaosMalformed {
+		return res, err
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	// half a JSON document, or a lone brace if there was no body, never
+	// decodes
+	body = body[:len(body)/2]
+	if len(body) == 0 {
+		body = []byte("{")
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+	res.Header.Del("Content-Length")
+	res.Header.Del("Content-Encoding")
+	res.Uncompressed = true
+	return res, nil
+}
@@ -0,0 +1,107 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestChaos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"item":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	t.Run("reset", func(t *testing.T) {
+		is := is.New(t)
+		client := NewClient(srv.URL, WithChaos(&Chaos{ResetProbability: 1}))
+		_, err := client.Run(ctx, NewRequest("query { item }"), nil)
+		var transportErr *TransportError
+		is.True(errors.As(err, &transportErr))
+		is.True(errors.Is(err, syscall.ECONNRESET))
+	})
+	t.Run("error", func(t *testing.T) {
+		is := is.New(t)
+		client := NewClient(srv.URL, WithChaos(&Chaos{ErrorProbability: 1, ErrorStatus: http.StatusBadGateway}))
+		_, err := client.Run(ctx, NewRequest("query { item }"), nil)
+		var httpErr *HTTPError
+		is.True(errors.As(err, &httpErr))
+		is.Equal(httpErr.StatusCode, http.StatusBadGateway)
+	})
+	t.Run("malformed", func(t *testing.T) {
+		is := is.New(t)
+		client := NewClient(srv.URL, WithChaos(&Chaos{MalformedProbability: 1}))
+		_, err := client.Run(ctx, NewRequest("query { item }"), nil)
+		var httpErr *HTTPError
+		is.True(errors.As(err, &httpErr))
+		is.True(httpErr.Err != nil)
+		is.Equal(string(httpErr.Body), `{"data":{"item`)
+	})
+	t.Run("latency", func(t *testing.T) {
+		is := is.New(t)
+		client := NewClient(srv.URL, WithChaos(&Chaos{Latency: 50 * time.Millisecond, LatencyProbability: 1}))
+		start := time.Now()
+		_, err := client.Run(ctx, NewRequest("query { item }"), nil)
+		is.NoErr(err)
+		is.True(time.Since(start) >= 50*time.Millisecond)
+
+		short, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+		_, err = client.Run(short, NewRequest("query { item }"), nil)
+		is.True(errors.Is(err, context.DeadlineExceeded))
+	})
+	t.Run("retried", func(t *testing.T) {
+		is := is.New(t)
+		client := NewClient(srv.URL, WithChaos(&Chaos{ResetProbability: 0.5, Seed: 1}),
+			WithRetry(10, func(int) time.Duration { return 0 }))
+		for i := 0; i < 10; i++ {
+			_, err := client.Run(ctx, NewRequest("query { item }"), nil)
+			is.NoErr(err)
+		}
+	})
+}
+
+func TestChaosDeterministic(t *testing.T) {
+	is := is.New(t)
+
+	faults := func() []chaosFault {
+		ch := &Chaos{ResetProbability: 0.2, ErrorProbability: 0.2, MalformedProbability: 0.2, Seed: 42}
+		var faults []chaosFault
+		for i := 0; i < 20; i++ {
+			_, fault := ch.draw()
+			faults = append(faults, fault)
+		}
+		return faults
+	}
+	first := faults()
+	is.Equal(first, faults())
+	seen := make(map[chaosFault]bool)
+	for _, fault := range first {
+		seen[fault] = true
+	}
+	is.Equal(len(seen), 4) // every kind, including none, in 20 draws
+}
+
+func TestChaosRoundTripper(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not reach the server")
+	}))
+	defer srv.Close()
+
+	httpClient := &http.Client{Transport: (&Chaos{ErrorProbability: 1}).RoundTripper(nil)}
+	res, err := httpClient.Get(srv.URL)
+	is.NoErr(err)
+	defer res.Body.Close()
+	is.Equal(res.StatusCode, http.StatusServiceUnavailable)
+	is.Equal(res.Status, "503 Service Unavailable")
+}
@@ -0,0 +1,142 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrClientClosed is returned when running a request with a Client that
+// has been closed.
+var ErrClientClosed = errors.New("graphql: client is closed")
+
+// clientState tracks the resources owned by a Client.
+type clientState struct {
+	mu sync.Mutex
+	// closing stops new resources being created while Close tears down
+	// the existing ones; closed is set once that's done.
+	closing bool
+	closed  bool
+	queues  map[*Queue]struct{}
+	// draining is set by Shutdown to turn away new requests while the
+	// inflight ones finish.
+	draining bool
+	inflight sync.WaitGroup
+}
+
+// begin notes the start of a request, returning false if the client is
+// no longer accepting them.
+func (s *clientState) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining || s.closed {
+		return false
+	}
+	s.inflight.Add(1)
+	return true
+}
+
+// end notes that a request started with begin has finished.
+func (s *clientState) end() {
+	s.inflight.Done()
+}
+
+func (s *clientState) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *clientState) addQueue(q *Queue) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return false
+	}
+	if s.queues == nil {
+		s.queues = make(map[*Queue]struct{})
+	}
+	s.queues[q] = struct{}{}
+	return true
+}
+
+func (s *clientState) removeQueue(q *Queue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.queues, q)
+}
+
+// Close releases the resources held by the client: queues created with
+// NewQueue are closed, waiting for the requests already in them, and
+// idle HTTP connections are closed.
+// Requests run after Close return ErrClientClosed. Close doesn't wait
+// for requests that are already running; see Shutdown.
+//
+// The idle connections belong to the underlying http.Client, so if it is
+// shared (http.DefaultClient is used unless WithHTTPClient is given)
+// other users of it lose their idle connections too.
+func (c *Client) Close() error {
+	c.state.mu.Lock()
+	c.state.closing = true
+	queues := make([]*Queue, 0, len(c.state.queues))
+	for q := range c.state.queues {
+		queues = append(queues, q)
+	}
+	c.state.mu.Unlock()
+	for _, q := range queues {
+		q.Close()
+	}
+	c.state.mu.Lock()
+	c.state.closed = true
+	c.state.mu.Unlock()
+	c.CloseIdleConnections()
+	return nil
+}
+
+// Shutdown closes the client gracefully, for services that are being
+// stopped as part of a deploy. It stops the client accepting new
+// requests, which return ErrClientClosed, then waits for the requests
+// already running and for the queues created with NewQueue to finish the
+// requests in them, before closing idle HTTP connections as Close does.
+//
+// If ctx is done first Shutdown closes the client without waiting any
+// longer and returns the context's error. Requests still running are
+// left to finish, but queued requests that haven't started fail with
+// ErrClientClosed; they are kept in the QueueStore, so they can be
+// enqueued again once the service restarts.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.state.mu.Lock()
+	c.state.closing = true
+	c.state.draining = true
+	queues := make([]*Queue, 0, len(c.state.queues))
+	for q := range c.state.queues {
+		queues = append(queues, q)
+	}
+	c.state.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		for _, q := range queues {
+			q.Close()
+		}
+		c.state.inflight.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	c.state.mu.Lock()
+	c.state.closed = true
+	c.state.mu.Unlock()
+	c.CloseIdleConnections()
+	return err
+}
+
+// CloseIdleConnections closes any HTTP connections that are sitting idle
+// in the underlying http.Client, without otherwise affecting the client.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
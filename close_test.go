@@ -0,0 +1,168 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestClose(t *testing.T) {
+	is := is.New(t)
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithHTTPClient(&http.Client{}))
+	q := client.NewQueue()
+	for i := 0; i < 5; i++ {
+		is.NoErr(q.Enqueue(NewRequest("mutation {}")))
+	}
+	is.NoErr(client.Close())
+
+	is.Equal(atomic.LoadInt32(&calls), int32(5)) // queued requests drained
+	is.Equal(q.Enqueue(NewRequest("mutation {}")), ErrQueueClosed)
+	_, err := client.Run(context.Background(), NewRequest("query {}"), nil)
+	is.Equal(err, ErrClientClosed)
+}
+
+func TestShutdown(t *testing.T) {
+	is := is.New(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+		io.WriteString(w, `{"data":{"ok":true}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithHTTPClient(&http.Client{}))
+	q := client.NewQueue(WithQueueWorkers(1))
+	inflight := make(chan error)
+	go func() {
+		_, err := client.Run(context.Background(), NewRequest("query { ok }"), nil)
+		inflight <- err
+	}()
+	<-started
+	for i := 0; i < 3; i++ {
+		is.NoErr(q.Enqueue(NewRequest("mutation { ok }")))
+	}
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- client.Shutdown(context.Background())
+	}()
+	// new requests are turned away while the running one finishes
+	for {
+		_, err := client.Run(context.Background(), NewRequest("query { ok }"), nil)
+		if err == ErrClientClosed {
+			break
+		}
+		is.NoErr(err)
+	}
+	select {
+	case <-shutdown:
+		t.Fatal("Shutdown returned with a request running")
+	default:
+	}
+	close(release)
+	is.NoErr(<-inflight)
+	is.NoErr(<-shutdown)
+	is.Equal(atomic.LoadInt32(&calls) >= 4, true) // queued requests drained
+	is.Equal(q.Enqueue(NewRequest("mutation {}")), ErrQueueClosed)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	is := is.New(t)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithHTTPClient(&http.Client{}))
+	failed := make(chan error, 1)
+	q := client.NewQueue(
+		WithQueueWorkers(1),
+		WithQueueRetries(3, func(int) time.Duration { return time.Hour }),
+		WithQueueErrorHandler(func(req *Request, err error) { failed <- err }),
+	)
+	is.NoErr(q.Enqueue(NewRequest("mutation { first }")))
+	<-started
+	is.NoErr(q.Enqueue(NewRequest("mutation { second }")))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	is.Equal(client.Shutdown(ctx), context.DeadlineExceeded)
+	_, err := client.Run(context.Background(), NewRequest("query { ok }"), nil)
+	is.Equal(err, ErrClientClosed)
+
+	// the request left in the queue fails without waiting to be retried
+	close(release)
+	select {
+	case err := <-failed:
+		is.Equal(err, ErrClientClosed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued request was retried after the client closed")
+	}
+	is.Equal(atomic.LoadInt32(&calls), int32(1))
+}
+
+func TestShutdownTimeoutKeepsStoredRequests(t *testing.T) {
+	is := is.New(t)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithHTTPClient(&http.Client{}))
+	store := &memoryStore{pending: make(map[*Request]bool)}
+	q := client.NewQueue(WithQueueWorkers(1), WithQueueStore(store))
+	first := NewRequest("mutation { first }")
+	is.NoErr(q.Enqueue(first))
+	<-started
+	unsent := []*Request{NewRequest("mutation { second }"), NewRequest("mutation { third }")}
+	for _, req := range unsent {
+		is.NoErr(q.Enqueue(req))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	is.Equal(client.Shutdown(ctx), context.DeadlineExceeded)
+	close(release)
+	q.Close()
+
+	is.Equal(atomic.LoadInt32(&calls), int32(1))
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	is.True(!store.pending[first]) // sent, so removed
+	for _, req := range unsent {
+		is.True(store.pending[req]) // never sent, so kept
+	}
+}
@@ -0,0 +1,445 @@
+// Command graphql runs GraphQL requests from the command line using the
+// github.com/dkempner/graphql client.
+//
+// The query is read from the file named by the first argument, or from
+// standard input if there isn't one:
+//
+//	graphql -endpoint https://example.com/graphql -var id=123 query.graphql
+//	echo '{ viewer { login } }' | graphql -endpoint https://example.com/graphql -H "Authorization: Bearer $TOKEN"
+//
+// The data field of the response is printed as indented JSON, or as
+// received with -raw. GraphQL errors are reported on standard error and
+// make the command exit with a non-zero status.
+//
+// Subcommands work with the server's schema, or put it under load:
+//
+//	graphql introspect -endpoint URL [-json]
+//	graphql validate -schema SCHEMA file.graphql...
+//	graphql lint file.graphql...
+//	graphql diff OLD NEW
+//	graphql loadtest -endpoint URL [-rps N] [-concurrency N] [-duration D] query.graphql
+//
+// introspect prints the schema as SDL, or with -json as the
+// introspection result, which can be saved and used as a SCHEMA: each
+// SCHEMA is either such a file or the URL of an endpoint to introspect.
+// validate checks operations against the schema, lint checks them for
+// mistakes that need no schema to spot, such as unused variables, and
+// diff lists the changes between two schemas; all three exit with a
+// non-zero status if they find problems or breaking changes, for use in
+// build pipelines.
+//
+// loadtest sends the query repeatedly and reports latency percentiles
+// and error rates.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dkempner/graphql"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command with args, returning any error for main to
+// report.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "introspect":
+			return introspect(args[1:], stdout)
+		case "validate":
+			return validate(args[1:], stdin, stdout)
+		case "lint":
+			return lint(args[1:], stdin, stdout)
+		case "diff":
+			return diff(args[1:], stdout)
+		case "loadtest":
+			return loadtest(args[1:], stdin, stdout)
+		}
+	}
+	return query(args, stdin, stdout)
+}
+
+// query runs a query and prints the response data.
+func query(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("graphql", flag.ContinueOnError)
+	client := addClientFlags(flags)
+	var (
+		raw  = flags.Bool("raw", false, "print the response data as received instead of indented")
+		vars = make(variables)
+	)
+	flags.Var(vars, "var", "set a variable, as `key=value`; values that are valid JSON are sent as JSON, anything else as a string (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("graphql: expected at most one query file, got %d", flags.NArg())
+	}
+	q, err := readQuery(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	c, err := client.newClient(*client.endpoint)
+	if err != nil {
+		return err
+	}
+	req := graphql.NewRequest(q)
+	for key, value := range vars {
+		req.Var(key, value)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *client.timeout)
+	defer cancel()
+	var data json.RawMessage
+	if _, err := c.Run(ctx, req, &data); err != nil {
+		return err
+	}
+	return printJSON(stdout, data, *raw)
+}
+
+// loadtest runs a query repeatedly and prints a report of how the server
+// coped.
+func loadtest(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("graphql loadtest", flag.ContinueOnError)
+	client := addClientFlags(flags)
+	var (
+		rate        = flags.Float64("rps", 0, "requests to start per second (default as fast as they complete)")
+		concurrency = flags.Int("concurrency", 10, "number of requests to run at once")
+		duration    = flags.Duration("duration", 10*time.Second, "how long to run for")
+		vars        = make(variables)
+	)
+	flags.Var(vars, "var", "set a variable, as `key=value` (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("graphql: expected at most one query file, got %d", flags.NArg())
+	}
+	q, err := readQuery(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	c, err := client.newClient(*client.endpoint)
+	if err != nil {
+		return err
+	}
+	req := graphql.NewRequest(q)
+	for key, value := range vars {
+		req.Var(key, value)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *duration+*client.timeout)
+	defer cancel()
+	report := c.LoadTest(ctx, req,
+		graphql.WithLoadTestRate(*rate),
+		graphql.WithLoadTestConcurrency(*concurrency),
+		graphql.WithLoadTestDuration(*duration),
+	)
+	fmt.Fprintf(stdout, "requests:    %d in %s (%.1f/s)\n", report.Requests, report.Duration.Round(time.Millisecond), report.Rate)
+	fmt.Fprintf(stdout, "errors:      %.2f%% (transport %d, http %d, graphql %d)\n",
+		report.ErrorRate*100, report.TransportFailures, report.HTTPFailures, report.GraphQLFailures)
+	fmt.Fprintf(stdout, "latency:     p50 %s, p90 %s, p99 %s, max %s\n",
+		report.LatencyP50, report.LatencyP90, report.LatencyP99, report.LatencyMax)
+	if report.Missed > 0 {
+		fmt.Fprintf(stdout, "missed:      %d requests, raise -concurrency to reach -rps\n", report.Missed)
+	}
+	return nil
+}
+
+// introspect prints the schema of an endpoint.
+func introspect(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("graphql introspect", flag.ContinueOnError)
+	client := addClientFlags(flags)
+	asJSON := flags.Bool("json", false, "print the introspection result as JSON instead of SDL")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("graphql: introspect: unexpected argument %q", flags.Arg(0))
+	}
+	schema, err := client.loadSchema(*client.endpoint)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		b, err := json.Marshal(introspectionResult{Schema: schema})
+		if err != nil {
+			return errors.Wrap(err, "graphql: encode schema")
+		}
+		return printJSON(stdout, b, false)
+	}
+	_, err = fmt.Fprint(stdout, schema.SDL())
+	return err
+}
+
+// validate checks operations against a schema.
+func validate(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("graphql validate", flag.ContinueOnError)
+	client := addClientFlags(flags)
+	source := flags.String("schema", "", "introspection JSON `file` or endpoint URL to validate against (default -endpoint)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *source == "" {
+		*source = *client.endpoint
+	}
+	schema, err := client.loadSchema(*source)
+	if err != nil {
+		return err
+	}
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var failed int
+	for _, name := range files {
+		q, err := readQuery(name, stdin)
+		if err != nil {
+			return err
+		}
+		if name == "-" {
+			name = "<stdin>"
+		}
+		for _, err := range schema.Validate(q) {
+			failed++
+			fmt.Fprintf(stdout, "%s: %s\n", name, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("graphql: validate: %d errors", failed)
+	}
+	return nil
+}
+
+// lint checks operations for problems that need no schema to spot.
+func lint(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("graphql lint", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var failed int
+	for _, name := range files {
+		q, err := readQuery(name, stdin)
+		if err != nil {
+			return err
+		}
+		if name == "-" {
+			name = "<stdin>"
+		}
+		diagnostics, err := graphql.Lint(q)
+		if err != nil {
+			failed++
+			fmt.Fprintf(stdout, "%s: %s\n", name, err)
+			continue
+		}
+		for _, d := range diagnostics {
+			failed++
+			fmt.Fprintf(stdout, "%s:%s\n", name, d)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("graphql: lint: %d problems", failed)
+	}
+	return nil
+}
+
+// diff prints the changes between two schemas.
+func diff(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("graphql diff", flag.ContinueOnError)
+	client := addClientFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("graphql: diff: expected an old and a new schema")
+	}
+	oldSchema, err := client.loadSchema(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	newSchema, err := client.loadSchema(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	var breaking int
+	for _, change := range graphql.DiffSchemas(oldSchema, newSchema) {
+		if change.Breaking {
+			breaking++
+		}
+		fmt.Fprintln(stdout, change)
+	}
+	if breaking > 0 {
+		return fmt.Errorf("graphql: diff: %d breaking changes", breaking)
+	}
+	return nil
+}
+
+// clientFlags are the flags shared by every command that talks to a
+// server.
+type clientFlags struct {
+	endpoint *string
+	timeout  *time.Duration
+	headers  headerFlags
+}
+
+func addClientFlags(flags *flag.FlagSet) *clientFlags {
+	f := &clientFlags{
+		endpoint: flags.String("endpoint", os.Getenv("GRAPHQL_ENDPOINT"), "GraphQL endpoint `url` (default $GRAPHQL_ENDPOINT)"),
+		timeout:  flags.Duration("timeout", time.Minute, "how long to wait for the response"),
+	}
+	flags.Var(&f.headers, "H", "add a request header, as `\"Name: value\"` (repeatable)")
+	return f
+}
+
+func (f *clientFlags) newClient(endpoint string) (*graphql.Client, error) {
+	if endpoint == "" {
+		return nil, errors.New("graphql: no endpoint given, use -endpoint or set GRAPHQL_ENDPOINT")
+	}
+	opts := make([]graphql.ClientOption, len(f.headers))
+	for i, header := range f.headers {
+		opts[i] = graphql.WithHeader(header.name, header.value)
+	}
+	return graphql.NewClientE(endpoint, opts...)
+}
+
+// introspectionResult is the data of an introspection response, the
+// format schema files are saved in.
+type introspectionResult struct {
+	Schema *graphql.Schema `json:"__schema"`
+}
+
+// loadSchema loads a schema from source, which is either the URL of an
+// endpoint to introspect or a file holding an introspection result.
+func (f *clientFlags) loadSchema(source string) (*graphql.Schema, error) {
+	if source == "" || strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		c, err := f.newClient(source)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), *f.timeout)
+		defer cancel()
+		return c.Introspect(ctx)
+	}
+	b, err := os.ReadFile(source)
+	if err != nil {
+		return nil, errors.Wrap(err, "graphql: read schema")
+	}
+	// accept a whole response as well as just its data
+	var file struct {
+		introspectionResult
+		Data *introspectionResult `json:"data"`
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, errors.Wrapf(err, "graphql: decode schema %s", source)
+	}
+	if file.Data != nil {
+		file.introspectionResult = *file.Data
+	}
+	if file.Schema == nil {
+		return nil, fmt.Errorf("graphql: %s is not an introspection result", source)
+	}
+	return file.Schema, nil
+}
+
+// readQuery reads the query from the named file, or from stdin if name
+// is empty or "-".
+func readQuery(name string, stdin io.Reader) (string, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if name == "" || name == "-" {
+		b, err = io.ReadAll(stdin)
+	} else {
+		b, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "graphql: read query")
+	}
+	if strings.TrimSpace(string(b)) == "" {
+		return "", errors.New("graphql: empty query")
+	}
+	return string(b), nil
+}
+
+// printJSON writes data to w, indented unless raw is set.
+func printJSON(w io.Writer, data json.RawMessage, raw bool) error {
+	if !raw && len(data) > 0 {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", "  "); err != nil {
+			return errors.Wrap(err, "graphql: format response")
+		}
+		data = buf.Bytes()
+	}
+	_, err := fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// variables collects -var flags.
+type variables map[string]interface{}
+
+func (v variables) String() string {
+	return ""
+}
+
+func (v variables) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	v[key] = decodeValue(value)
+	return nil
+}
+
+// decodeValue decodes a -var value as JSON, keeping numbers exactly as
+// they were written so large IDs aren't rounded, or returns it as a
+// string if it isn't JSON.
+func decodeValue(value string) interface{} {
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		return value
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		// more follows the first value, so it isn't JSON
+		return value
+	}
+	return decoded
+}
+
+// headerFlags collects -H flags.
+type headerFlags []header
+
+type header struct {
+	name, value string
+}
+
+func (h *headerFlags) String() string {
+	return ""
+}
+
+func (h *headerFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected \"Name: value\", got %q", s)
+	}
+	*h = append(*h, header{name: strings.TrimSpace(name), value: strings.TrimSpace(value)})
+	return nil
+}
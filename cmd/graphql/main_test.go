@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRun(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("Authorization"), "Bearer token")
+		var body struct {
+			Query     string
+			Variables map[string]interface{}
+		}
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+		is.NoErr(dec.Decode(&body))
+		is.Equal(body.Query, "query ($id: ID!, $n: Int) { item(id: $id) }\n")
+		is.Equal(body.Variables["id"], "abc")
+		is.Equal(body.Variables["n"], json.Number("3"))
+		is.Equal(body.Variables["big"], json.Number("9007199254740993"))
+		is.Equal(body.Variables["words"], "1 2")
+		io.WriteString(w, `{"data":{"item":{"name":"some data"}}}`)
+	}))
+	defer srv.Close()
+
+	query := "query ($id: ID!, $n: Int) { item(id: $id) }\n"
+	var out bytes.Buffer
+	err := run([]string{"-endpoint", srv.URL, "-var", "id=abc", "-var", "n=3", "-var", "big=9007199254740993", "-var", "words=1 2", "-H", "Authorization: Bearer token"}, strings.NewReader(query), &out)
+	is.NoErr(err)
+	is.Equal(out.String(), "{\n  \"item\": {\n    \"name\": \"some data\"\n  }\n}\n")
+
+	out.Reset()
+	err = run([]string{"-endpoint", srv.URL, "-raw", "-var", "id=abc", "-var", "n=3", "-var", "big=9007199254740993", "-var", "words=1 2", "-H", "Authorization: Bearer token", "-"}, strings.NewReader(query), &out)
+	is.NoErr(err)
+	is.Equal(out.String(), `{"item":{"name":"some data"}}`+"\n")
+}
+
+func TestRunErrors(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"errors":[{"message":"Something went wrong"}]}`)
+	}))
+	defer srv.Close()
+
+	err := run([]string{"-endpoint", srv.URL}, strings.NewReader("{ item }"), io.Discard)
+	is.Equal(err.Error(), "graphql: Something went wrong")
+	err = run([]string{"-endpoint", srv.URL}, strings.NewReader(" \n"), io.Discard)
+	is.Equal(err.Error(), "graphql: empty query")
+	err = run([]string{"-endpoint", srv.URL, "-var", "novalue"}, strings.NewReader("{ item }"), io.Discard)
+	is.True(err != nil)
+}
+
+const testSchema = `{"__schema": {
+	"queryType": {"name": "Query"},
+	"types": [
+		{"kind": "SCALAR", "name": "String"},
+		{"kind": "OBJECT", "name": "Query", "fields": [
+			{"name": "hello", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+		]}
+	]
+}}`
+
+func TestSchemaCommands(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("Authorization"), "Bearer token")
+		io.WriteString(w, `{"data":`+testSchema+`}`)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	err := run([]string{"introspect", "-endpoint", srv.URL, "-H", "Authorization: Bearer token"}, nil, &out)
+	is.NoErr(err)
+	is.Equal(out.String(), "type Query {\n  hello: String\n}\n")
+
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "schema.json")
+	out.Reset()
+	err = run([]string{"introspect", "-endpoint", srv.URL, "-H", "Authorization: Bearer token", "-json"}, nil, &out)
+	is.NoErr(err)
+	is.NoErr(os.WriteFile(schemaFile, out.Bytes(), 0o644))
+
+	out.Reset()
+	err = run([]string{"validate", "-schema", schemaFile}, strings.NewReader("{ hello }"), &out)
+	is.NoErr(err)
+	is.Equal(out.String(), "")
+	err = run([]string{"validate", "-schema", schemaFile}, strings.NewReader("{ goodbye }"), &out)
+	is.Equal(err.Error(), "graphql: validate: 1 errors")
+	is.Equal(out.String(), "<stdin>: graphql: validation error at 1:3: cannot query field \"goodbye\" on type \"Query\"\n")
+
+	out.Reset()
+	err = run([]string{"lint"}, strings.NewReader("query ($id: ID) { hello }"), &out)
+	is.Equal(err.Error(), "graphql: lint: 1 problems")
+	is.Equal(out.String(), "<stdin>:1:8: variable $id is never used (unused-variable)\n")
+	out.Reset()
+	err = run([]string{"lint"}, strings.NewReader("{ hello }"), &out)
+	is.NoErr(err)
+	is.Equal(out.String(), "")
+
+	newSchema := filepath.Join(dir, "new.json")
+	is.NoErr(os.WriteFile(newSchema, []byte(strings.Replace(testSchema, "hello", "goodbye", 1)), 0o644))
+	out.Reset()
+	err = run([]string{"diff", "-H", "Authorization: Bearer token", srv.URL, newSchema}, nil, &out)
+	is.Equal(err.Error(), "graphql: diff: 1 breaking changes")
+	is.Equal(out.String(), "BREAKING: field \"Query.hello\" removed\nfield \"Query.goodbye\" added\n")
+}
+
+func TestLoadtestCommand(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"hello":"world"}}`)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	err := run([]string{"loadtest", "-endpoint", srv.URL, "-rps", "100", "-concurrency", "2", "-duration", "100ms"}, strings.NewReader("{ hello }"), &out)
+	is.NoErr(err)
+	is.True(strings.HasPrefix(out.String(), "requests:    "))
+	is.True(strings.Contains(out.String(), "errors:      0.00% (transport 0, http 0, graphql 0)\n"))
+}
@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// Cost is what a request cost against a server's query cost limit, and
+// the state of that limit afterwards, as reported in the extensions of
+// the response. Fields the server didn't report are zero.
+type Cost struct {
+	// Requested is the cost the server estimated before running the
+	// query.
+	Requested float64
+	// Actual is what running the query actually cost.
+	Actual float64
+	// Limit is the most that may be available at once.
+	Limit float64
+	// Available is what is available now.
+	Available float64
+	// RestoreRate is how much becomes available again each second.
+	RestoreRate float64
+	// Throttled is set if the server refused the request for costing
+	// more than was available.
+	Throttled bool
+}
+
+// Wait returns how long to wait before a request costing cost can run,
+// given what is available and how quickly it is restored. It returns
+// zero if the request can run now, or if the restore rate isn't known.
+func (c Cost) Wait(cost float64) time.Duration {
+	if cost <= c.Available || c.RestoreRate <= 0 {
+		return 0
+	}
+	seconds := (cost - c.Available) / c.RestoreRate
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+// CostParser reads the cost of a request from the extensions of its
+// response, reporting false if they don't give it. Numbers are float64,
+// or json.Number if the client was created with WithNumbers.
+type CostParser func(extensions map[string]interface{}) (Cost, bool)
+
+// WithCostParser sets the parser used to read Response.Cost, and the
+// cost of throttled requests, for servers that report cost in their own
+// shape. The default is ParseCostExtension.
+func WithCostParser(p CostParser) ClientOption {
+	return func(client *Client) {
+		client.costParser = p
+	}
+}
+
+// ParseCostExtension reads cost in the shape Shopify introduced and
+// other APIs have since adopted:
+//
+//	"extensions": {
+//	  "cost": {
+//	    "requestedQueryCost": 101,
+//	    "actualQueryCost": 46,
+//	    "throttleStatus": {
+//	      "maximumAvailable": 1000,
+//	      "currentlyAvailable": 954,
+//	      "restoreRate": 50
+//	    }
+//	  }
+//	}
+//
+// A request is counted as throttled if an error in the response has the
+// code THROTTLED, which the parser can't see; Response.Cost sets
+// Throttled itself.
+func ParseCostExtension(extensions map[string]interface{}) (Cost, bool) {
+	obj, ok := extensions["cost"].(map[string]interface{})
+	if !ok {
+		return Cost{}, false
+	}
+	var c Cost
+	c.Requested, _ = costNumber(obj["requestedQueryCost"])
+	c.Actual, _ = costNumber(obj["actualQueryCost"])
+	if status, ok := obj["throttleStatus"].(map[string]interface{}); ok {
+		c.Limit, _ = costNumber(status["maximumAvailable"])
+		c.Available, _ = costNumber(status["currentlyAvailable"])
+		c.RestoreRate, _ = costNumber(status["restoreRate"])
+	}
+	return c, true
+}
+
+// costNumber returns the number v, decoded as float64 or json.Number.
+func costNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Cost returns the cost of the request as the client's CostParser reads
+// it from the response, or nil if the response doesn't report one.
+func (r *Response) Cost() *Cost {
+	if r == nil || r.costParser == nil {
+		return nil
+	}
+	extensions, _ := r.Query("extensions").(map[string]interface{})
+	c, ok := r.costParser(extensions)
+	if !ok {
+		return nil
+	}
+	codes, _ := r.Query("errors.#.extensions.code").([]interface{})
+	for _, code := range codes {
+		if code == "THROTTLED" {
+			c.Throttled = true
+		}
+	}
+	return &c
+}
@@ -0,0 +1,69 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestResponseCost(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"item":"some data"},"extensions":{"cost":{
+			"requestedQueryCost":101,"actualQueryCost":46,
+			"throttleStatus":{"maximumAvailable":1000,"currentlyAvailable":954,"restoreRate":50}
+		}}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	want := &Cost{Requested: 101, Actual: 46, Limit: 1000, Available: 954, RestoreRate: 50}
+	res, err := NewClient(srv.URL).Run(ctx, NewRequest("query { item }"), nil)
+	is.NoErr(err)
+	is.Equal(res.Cost(), want)
+
+	res, err = NewClient(srv.URL, WithNumbers()).Run(ctx, NewRequest("query { item }"), nil)
+	is.NoErr(err)
+	is.Equal(res.Cost(), want)
+
+	custom := func(extensions map[string]interface{}) (Cost, bool) {
+		return Cost{Available: 1}, true
+	}
+	res, err = NewClient(srv.URL, WithCostParser(custom)).Run(ctx, NewRequest("query { item }"), nil)
+	is.NoErr(err)
+	is.Equal(res.Cost(), &Cost{Available: 1})
+
+	var none *Response
+	is.Equal(none.Cost(), nil)
+}
+
+func TestResponseCostThrottled(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"errors":[{"message":"Throttled","extensions":{"code":"THROTTLED"}}],
+			"extensions":{"cost":{"requestedQueryCost":200,
+			"throttleStatus":{"maximumAvailable":1000,"currentlyAvailable":100,"restoreRate":50}}}}`)
+	}))
+	defer srv.Close()
+
+	res, err := NewClient(srv.URL).Run(context.Background(), NewRequest("query { item }"), nil)
+	is.True(err != nil)
+	cost := res.Cost()
+	is.True(cost.Throttled)
+	is.Equal(cost.Wait(cost.Requested), 2*time.Second)
+}
+
+func TestCostWait(t *testing.T) {
+	is := is.New(t)
+	c := Cost{Available: 10, RestoreRate: 4}
+	is.Equal(c.Wait(5), time.Duration(0))
+	is.Equal(c.Wait(12), 500*time.Millisecond)
+	is.Equal(Cost{Available: 1}.Wait(12), time.Duration(0))
+}
@@ -0,0 +1,40 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// WithStrictDecoding makes Run fail when the response data has fields
+// the response object doesn't declare, rather than ignoring them. This
+// is useful in tests to catch response structs drifting from the
+// schema. Fields of the response envelope other than data, such as
+// extensions, are still allowed.
+func WithStrictDecoding() ClientOption {
+	return func(client *Client) {
+		client.strictDecoding = true
+	}
+}
+
+// WithNumbers decodes numbers in the response data as json.Number rather
+// than float64 wherever the response object leaves their type open, such
+// as in a map[string]interface{}, so that 64-bit IDs and amounts keep
+// their precision. Numbers decoded into typed fields already keep the
+// precision of the field's type.
+func WithNumbers() ClientOption {
+	return func(client *Client) {
+		client.useNumber = true
+	}
+}
+
+// decodeData decodes data, the data field of a response, into v.
+func (c *Client) decodeData(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if c.strictDecoding {
+		dec.DisallowUnknownFields()
+	}
+	if c.useNumber {
+		dec.UseNumber()
+	}
+	return dec.Decode(v)
+}
@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWithStrictDecoding(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"item":{"name":"some data","price":10}},"extensions":{"cost":1}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	var resp struct {
+		Item struct {
+			Name string
+		}
+	}
+	_, err := NewClient(srv.URL).Run(ctx, NewRequest("query {}"), &resp)
+	is.NoErr(err)
+	is.Equal(resp.Item.Name, "some data")
+
+	_, err = NewClient(srv.URL, WithStrictDecoding()).Run(ctx, NewRequest("query {}"), &resp)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `unknown field "price"`))
+
+	var full struct {
+		Item struct {
+			Name  string
+			Price int
+		}
+	}
+	_, err = NewClient(srv.URL, WithStrictDecoding()).Run(ctx, NewRequest("query {}"), &full)
+	is.NoErr(err) // extensions are allowed
+	is.Equal(full.Item.Price, 10)
+}
+
+func TestWithNumbers(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"id":9007199254740993,"amount":12.10}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	var resp map[string]interface{}
+	_, err := NewClient(srv.URL).Run(ctx, NewRequest("query {}"), &resp)
+	is.NoErr(err)
+	is.Equal(resp["id"], float64(9007199254740992)) // precision lost
+
+	resp = nil
+	_, err = NewClient(srv.URL, WithNumbers()).Run(ctx, NewRequest("query {}"), &resp)
+	is.NoErr(err)
+	is.Equal(resp["id"], json.Number("9007199254740993"))
+	is.Equal(resp["amount"], json.Number("12.10"))
+
+	var typed struct {
+		ID int64
+	}
+	_, err = NewClient(srv.URL).Run(ctx, NewRequest("query {}"), &typed)
+	is.NoErr(err)
+	is.Equal(typed.ID, int64(9007199254740993))
+}
@@ -0,0 +1,173 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SchemaChange is a difference between two schemas found by DiffSchemas.
+type SchemaChange struct {
+	// Breaking is set when the change can break existing clients, such as
+	// removing a field or making an argument required.
+	Breaking bool
+	// Message describes the change.
+	Message string
+}
+
+func (c SchemaChange) String() string {
+	if c.Breaking {
+		return "BREAKING: " + c.Message
+	}
+	return c.Message
+}
+
+// DiffSchemas compares two versions of a schema, returning the changes
+// made to types, fields, arguments and enum values in going from old to
+// new, sorted so breaking changes come first.
+func DiffSchemas(old, new *Schema) []SchemaChange {
+	d := &schemaDiff{}
+	for _, root := range []struct {
+		op       string
+		old, new *TypeRef
+	}{
+		{"query", old.QueryType, new.QueryType},
+		{"mutation", old.MutationType, new.MutationType},
+		{"subscription", old.SubscriptionType, new.SubscriptionType},
+	} {
+		switch {
+		case root.old != nil && root.new == nil:
+			d.add(true, "%s root type removed", root.op)
+		case root.old == nil && root.new != nil:
+			d.add(false, "%s root type %q added", root.op, root.new.Name)
+		case root.old != nil && root.old.Name != root.new.Name:
+			d.add(true, "%s root type changed from %q to %q", root.op, root.old.Name, root.new.Name)
+		}
+	}
+	for _, oldType := range old.Types {
+		newType := new.Type(oldType.Name)
+		switch {
+		case newType == nil:
+			d.add(true, "type %q removed", oldType.Name)
+		case newType.Kind != oldType.Kind:
+			d.add(true, "type %q changed from %s to %s", oldType.Name, oldType.Kind, newType.Kind)
+		default:
+			d.types(&oldType, newType)
+		}
+	}
+	for _, newType := range new.Types {
+		if old.Type(newType.Name) == nil {
+			d.add(false, "type %q added", newType.Name)
+		}
+	}
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		return d.changes[i].Breaking && !d.changes[j].Breaking
+	})
+	return d.changes
+}
+
+type schemaDiff struct {
+	changes []SchemaChange
+}
+
+func (d *schemaDiff) add(breaking bool, format string, args ...interface{}) {
+	d.changes = append(d.changes, SchemaChange{Breaking: breaking, Message: fmt.Sprintf(format, args...)})
+}
+
+// types compares two versions of a type of the same kind.
+func (d *schemaDiff) types(old, new *SchemaType) {
+	for _, oldField := range old.Fields {
+		name := old.Name + "." + oldField.Name
+		newField := new.Field(oldField.Name)
+		if newField == nil {
+			d.add(true, "field %q removed", name)
+			continue
+		}
+		if oldField.Type.String() != newField.Type.String() {
+			d.add(true, "field %q changed type from %s to %s", name, oldField.Type, newField.Type)
+		}
+		if !oldField.IsDeprecated && newField.IsDeprecated {
+			d.add(false, "field %q deprecated", name)
+		}
+		d.inputValues("argument", name, oldField.Args, newField.Args)
+	}
+	for _, newField := range new.Fields {
+		if old.Field(newField.Name) == nil {
+			d.add(false, "field %q added", old.Name+"."+newField.Name)
+		}
+	}
+	d.inputValues("input field", old.Name, old.InputFields, new.InputFields)
+	for _, value := range old.EnumValues {
+		if !hasEnumValue(new.EnumValues, value.Name) {
+			d.add(true, "enum value %q removed", old.Name+"."+value.Name)
+		}
+	}
+	for _, value := range new.EnumValues {
+		if !hasEnumValue(old.EnumValues, value.Name) {
+			d.add(false, "enum value %q added", old.Name+"."+value.Name)
+		}
+	}
+	for _, member := range old.PossibleTypes {
+		if old.Kind == "UNION" && !hasTypeRef(new.PossibleTypes, member.Name) {
+			d.add(true, "type %q removed from union %q", member.Name, old.Name)
+		}
+	}
+	for _, member := range new.PossibleTypes {
+		if new.Kind == "UNION" && !hasTypeRef(old.PossibleTypes, member.Name) {
+			d.add(false, "type %q added to union %q", member.Name, old.Name)
+		}
+	}
+}
+
+// inputValues compares the arguments of a field, or the fields of an
+// input type.
+func (d *schemaDiff) inputValues(what, owner string, old, new []SchemaInputValue) {
+	for _, oldValue := range old {
+		name := owner + "." + oldValue.Name
+		newValue := findInputValue(new, oldValue.Name)
+		if newValue == nil {
+			d.add(true, "%s %q removed", what, name)
+			continue
+		}
+		if oldValue.Type.String() != newValue.Type.String() {
+			d.add(true, "%s %q changed type from %s to %s", what, name, oldValue.Type, newValue.Type)
+		}
+	}
+	for _, newValue := range new {
+		if findInputValue(old, newValue.Name) != nil {
+			continue
+		}
+		required := newValue.Type.Kind == "NON_NULL" && newValue.DefaultValue == nil
+		if required {
+			d.add(true, "required %s %q added", what, owner+"."+newValue.Name)
+		} else {
+			d.add(false, "%s %q added", what, owner+"."+newValue.Name)
+		}
+	}
+}
+
+func findInputValue(values []SchemaInputValue, name string) *SchemaInputValue {
+	for i := range values {
+		if values[i].Name == name {
+			return &values[i]
+		}
+	}
+	return nil
+}
+
+func hasEnumValue(values []SchemaEnumValue, name string) bool {
+	for _, value := range values {
+		if value.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTypeRef(refs []TypeRef, name string) bool {
+	for _, ref := range refs {
+		if ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
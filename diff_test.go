@@ -0,0 +1,40 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(DiffSchemas(testSchema(), testSchema()), []SchemaChange(nil))
+
+	new := testSchema()
+	query := new.Type("Query")
+	query.Fields = query.Fields[1:] // remove item
+	query.Fields[0].Args = append(query.Fields[0].Args, SchemaInputValue{Name: "after", Type: nonNull(named("SCALAR", "String"))})
+	item := new.Type("Item")
+	item.Fields[1].Type = nonNull(named("SCALAR", "String"))
+	item.Fields = append(item.Fields, SchemaField{Name: "price", Type: named("SCALAR", "Int")})
+	status := new.Type("Status")
+	status.EnumValues = []SchemaEnumValue{{Name: "ACTIVE"}, {Name: "RESERVED"}}
+	new.Types = append(new.Types, SchemaType{Kind: "SCALAR", Name: "Money"})
+	new.MutationType = nil
+
+	var changes []string
+	for _, change := range DiffSchemas(testSchema(), new) {
+		changes = append(changes, change.String())
+	}
+	is.Equal(changes, []string{
+		`BREAKING: mutation root type removed`,
+		`BREAKING: field "Query.item" removed`,
+		`BREAKING: required argument "Query.items.after" added`,
+		`BREAKING: field "Item.name" changed type from String to String!`,
+		`BREAKING: enum value "Status.SOLD" removed`,
+		`field "Item.price" added`,
+		`enum value "Status.RESERVED" added`,
+		`type "Money" added`,
+	})
+}
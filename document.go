@@ -0,0 +1,369 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind is the kind of a lexical token in a GraphQL document.
+type tokenKind int
+
+const (
+	tokenPunct tokenKind = iota
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+// token is a single lexical token of a GraphQL document.
+type token struct {
+	kind tokenKind
+	// text is the token exactly as it appears in the source.
+	text string
+	line int
+	col  int
+}
+
+func (t token) is(text string) bool {
+	return t.kind == tokenPunct && t.text == text
+}
+
+func (t token) isName(name string) bool {
+	return t.kind == tokenName && t.text == name
+}
+
+// lex splits a GraphQL document into tokens. Whitespace, commas and
+// comments are insignificant and are dropped.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	line, lineStart := 1, 0
+	for i := 0; i < len(src); {
+		c := src[i]
+		col := i - lineStart + 1
+		switch {
+		case c == '\n':
+			i++
+			line, lineStart = line+1, i
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '.':
+			if !strings.HasPrefix(src[i:], "...") {
+				return nil, fmt.Errorf("graphql: syntax error at %d:%d: unexpected %q", line, col, c)
+			}
+			tokens = append(tokens, token{kind: tokenPunct, text: "...", line: line, col: col})
+			i += 3
+		case strings.IndexByte("!$&():=@[]{|}", c) >= 0:
+			tokens = append(tokens, token{kind: tokenPunct, text: string(c), line: line, col: col})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenName, text: src[start:i], line: line, col: col})
+		case c == '-' || isDigit(c):
+			start := i
+			kind := tokenInt
+			i++
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				kind = tokenFloat
+				i++
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = tokenFloat
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: kind, text: src[start:i], line: line, col: col})
+		case c == '"':
+			start := i
+			if strings.HasPrefix(src[i:], `"""`) {
+				i += 3
+				for i < len(src) && !strings.HasPrefix(src[i:], `"""`) {
+					if strings.HasPrefix(src[i:], `\"""`) {
+						i += 3
+					}
+					i++
+				}
+				if i >= len(src) {
+					return nil, fmt.Errorf("graphql: syntax error at %d:%d: unterminated block string", line, col)
+				}
+				i += 3
+				line += strings.Count(src[start:i], "\n")
+				if nl := strings.LastIndexByte(src[start:i], '\n'); nl >= 0 {
+					lineStart = start + nl + 1
+				}
+			} else {
+				i++
+				for i < len(src) && src[i] != '"' {
+					if src[i] == '\n' {
+						return nil, fmt.Errorf("graphql: syntax error at %d:%d: unterminated string", line, col)
+					}
+					if src[i] == '\\' {
+						i++
+					}
+					i++
+				}
+				if i >= len(src) {
+					return nil, fmt.Errorf("graphql: syntax error at %d:%d: unterminated string", line, col)
+				}
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: src[start:i], line: line, col: col})
+		default:
+			return nil, fmt.Errorf("graphql: syntax error at %d:%d: unexpected %q", line, col, c)
+		}
+	}
+	return tokens, nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// document is a lightly parsed GraphQL document. Only the structure the
+// client needs is recovered: the top level definitions and the token
+// ranges that make them up.
+type document struct {
+	tokens     []token
+	operations []*operationDef
+	fragments  []*fragmentDef
+}
+
+// operationDef is an operation definition within a document. Token
+// indexes are half open ranges into document.tokens.
+type operationDef struct {
+	// typ is query, mutation or subscription.
+	typ  string
+	name string
+	// start and end span the whole definition.
+	start, end int
+	// varsStart and varsEnd span the variable definitions, excluding the
+	// surrounding parentheses. Both are zero when there are none.
+	varsStart, varsEnd int
+	// selStart and selEnd span the selection set, including its braces.
+	selStart, selEnd int
+}
+
+// fragmentDef is a fragment definition within a document.
+type fragmentDef struct {
+	name       string
+	start, end int
+}
+
+// parseDocument parses the top level structure of a GraphQL document.
+func parseDocument(src string) (*document, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	doc := &document{tokens: tokens}
+	for i := 0; i < len(tokens); {
+		t := tokens[i]
+		switch {
+		case t.is("{"):
+			end, err := doc.skipBalanced(i)
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operationDef{
+				typ:      "query",
+				start:    i,
+				end:      end,
+				selStart: i,
+				selEnd:   end,
+			})
+			i = end
+		case t.isName("query") || t.isName("mutation") || t.isName("subscription"):
+			op := &operationDef{typ: t.text, start: i}
+			i++
+			if i < len(tokens) && tokens[i].kind == tokenName {
+				op.name = tokens[i].text
+				i++
+			}
+			if i < len(tokens) && tokens[i].is("(") {
+				end, err := doc.skipBalanced(i)
+				if err != nil {
+					return nil, err
+				}
+				op.varsStart, op.varsEnd = i+1, end-1
+				i = end
+			}
+			if i, err = doc.skipToSelectionSet(i); err != nil {
+				return nil, err
+			}
+			end, err := doc.skipBalanced(i)
+			if err != nil {
+				return nil, err
+			}
+			op.selStart, op.selEnd, op.end = i, end, end
+			doc.operations = append(doc.operations, op)
+			i = end
+		case t.isName("fragment"):
+			if i+1 >= len(tokens) || tokens[i+1].kind != tokenName {
+				return nil, doc.errorf(t, "expected fragment name")
+			}
+			frag := &fragmentDef{name: tokens[i+1].text, start: i}
+			i += 2
+			if i, err = doc.skipToSelectionSet(i); err != nil {
+				return nil, err
+			}
+			end, err := doc.skipBalanced(i)
+			if err != nil {
+				return nil, err
+			}
+			frag.end = end
+			doc.fragments = append(doc.fragments, frag)
+			i = end
+		default:
+			return nil, doc.errorf(t, "unexpected %q", t.text)
+		}
+	}
+	return doc, nil
+}
+
+// operationType returns the type of the operation in the document q:
+// query, mutation or subscription. If the document contains more than
+// one operation the first is used. It returns an empty string if q can't
+// be parsed.
+func operationType(q string) string {
+	doc, err := parseDocument(q)
+	if err != nil || len(doc.operations) == 0 {
+		return ""
+	}
+	return doc.operations[0].typ
+}
+
+// operationName returns the name of the operation in the document q, or
+// an empty string if it has none, or if q has more than one operation
+// or can't be parsed.
+func operationName(q string) string {
+	doc, err := parseDocument(q)
+	if err != nil || len(doc.operations) != 1 {
+		return ""
+	}
+	return doc.operations[0].name
+}
+
+// skipToSelectionSet returns the index of the selection set that starts
+// at or after tokens[i], skipping any directives in between.
+func (doc *document) skipToSelectionSet(i int) (int, error) {
+	start := i
+	for i < len(doc.tokens) && !doc.tokens[i].is("{") {
+		if doc.tokens[i].is("(") {
+			end, err := doc.skipBalanced(i)
+			if err != nil {
+				return 0, err
+			}
+			i = end
+			continue
+		}
+		i++
+	}
+	if i >= len(doc.tokens) {
+		return 0, doc.errorf(doc.tokens[start-1], "expected selection set")
+	}
+	return i, nil
+}
+
+// skipBalanced returns the index just past the bracket that closes the
+// one at tokens[i].
+func (doc *document) skipBalanced(i int) (int, error) {
+	open := doc.tokens[i].text
+	closer := map[string]string{"{": "}", "(": ")", "[": "]"}[open]
+	depth := 0
+	for j := i; j < len(doc.tokens); j++ {
+		switch {
+		case doc.tokens[j].is(open):
+			depth++
+		case doc.tokens[j].is(closer):
+			depth--
+			if depth == 0 {
+				return j + 1, nil
+			}
+		}
+	}
+	return 0, doc.errorf(doc.tokens[i], "unclosed %q", open)
+}
+
+func (doc *document) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("graphql: syntax error at %d:%d: %s", t.line, t.col, fmt.Sprintf(format, args...))
+}
+
+// rootFields returns the token index of the first token of each field in
+// the selection set spanning tokens[start:end]. If a field is
+// aliased the index is that of the alias.
+func (doc *document) rootFields(start, end int) ([]int, error) {
+	var fields []int
+	for i := start + 1; i < end-1; {
+		t := doc.tokens[i]
+		if t.is("...") {
+			return nil, doc.errorf(t, "fragments are not supported at the root of the selection set")
+		}
+		if t.kind != tokenName {
+			return nil, doc.errorf(t, "unexpected %q", t.text)
+		}
+		fields = append(fields, i)
+		i++
+		if i < end && doc.tokens[i].is(":") {
+			i += 2
+		}
+		for i < end-1 {
+			next := doc.tokens[i]
+			if next.is("(") || next.is("{") {
+				j, err := doc.skipBalanced(i)
+				if err != nil {
+					return nil, err
+				}
+				i = j
+				continue
+			}
+			if next.is("@") {
+				i += 2
+				continue
+			}
+			break
+		}
+	}
+	return fields, nil
+}
+
+// joinTokens renders tokens back into a document.
+func joinTokens(tokens []token) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		if i > 0 && !noSpaceBefore(t) && !noSpaceAfter(tokens[i-1]) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+func noSpaceBefore(t token) bool {
+	return t.is(":") || t.is("!") || t.is("(") || t.is(")") || t.is("]")
+}
+
+func noSpaceAfter(t token) bool {
+	return t.is("$") || t.is("@") || t.is("...") || t.is("(") || t.is("[")
+}
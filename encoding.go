@@ -0,0 +1,60 @@
+package graphql
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WithAcceptEncoding sets the Accept-Encoding header sent with every
+// request, for example to "gzip" or "gzip, deflate".
+//
+// Setting the header stops the http.Transport from transparently
+// decompressing responses and removing their Content-Encoding, so the
+// Response returned by Run describes the body as it was sent. The
+// client still decompresses gzip and deflate bodies itself in order to
+// decode them.
+func WithAcceptEncoding(encoding string) ClientOption {
+	return func(client *Client) {
+		client.acceptEncoding = encoding
+	}
+}
+
+// WithoutCompression asks the server not to compress responses.
+func WithoutCompression() ClientOption {
+	return WithAcceptEncoding("identity")
+}
+
+// decompress decodes a body compressed with the given Content-Encoding,
+// reading at most limit decompressed bytes if limit isn't negative.
+func decompress(encoding string, body []byte, limit int64) ([]byte, error) {
+	var r io.Reader
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		r = zr
+	case "deflate":
+		// deflate is meant to be zlib wrapped, but some servers send
+		// raw deflate data
+		if zr, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+			r = zr
+		} else {
+			r = flate.NewReader(bytes.NewReader(body))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+	if limit >= 0 {
+		r = io.LimitReader(r, limit)
+	}
+	return io.ReadAll(r)
+}
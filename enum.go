@@ -0,0 +1,122 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// GraphQLEnum is implemented by Go types representing GraphQL enums.
+// Variables of such types are checked before a request is sent, so an
+// invalid value fails at once rather than at the server, and are sent
+// as their names:
+//
+//	type Status string
+//
+//	func (s Status) EnumName() string     { return string(s) }
+//	func (Status) EnumValues() []string   { return []string{"ACTIVE", "SUSPENDED"} }
+//
+// Variables are encoded as JSON, where enum values are strings; they are
+// only unquoted when written as literals in the query document itself.
+type GraphQLEnum interface {
+	// EnumName returns the name of the value, such as ACTIVE.
+	EnumName() string
+	// EnumValues returns the names of all the values of the enum.
+	EnumValues() []string
+}
+
+var enumType = reflect.TypeOf((*GraphQLEnum)(nil)).Elem()
+
+func isEnumValue(enum GraphQLEnum, name string) bool {
+	for _, value := range enum.EnumValues() {
+		if value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateVariables checks the values of the variables vars for the
+// operations in the document q against the schema's enum types,
+// including enums within input objects and lists, returning an error
+// for each value the schema doesn't allow. Variables the operations
+// don't define, and types other than enums and input objects, are not
+// checked.
+func (s *Schema) ValidateVariables(q string, vars map[string]interface{}) []error {
+	doc, err := parseDocument(q)
+	if err != nil {
+		return []error{err}
+	}
+	// values are checked in the form the server sees them
+	b, err := json.Marshal(vars)
+	if err != nil {
+		return []error{errors.Wrap(err, "encode variables")}
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return []error{errors.Wrap(err, "decode variables")}
+	}
+	var errs []error
+	for _, op := range doc.operations {
+		if op.varsEnd == 0 {
+			continue
+		}
+		for _, def := range splitVariableDefinitions(doc.tokens[op.varsStart:op.varsEnd]) {
+			name := def[1].text
+			value, ok := values[name]
+			if !ok {
+				continue
+			}
+			for _, t := range def[2:] {
+				if t.kind == tokenName {
+					errs = s.checkValue(errs, "$"+name, s.Type(t.text), value)
+					break
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// checkValue appends to errs an error for each enum value within value,
+// of type typ, that the schema doesn't allow.
+func (s *Schema) checkValue(errs []error, path string, typ *SchemaType, value interface{}) []error {
+	if typ == nil {
+		return errs
+	}
+	if list, ok := value.([]interface{}); ok {
+		for i, elem := range list {
+			errs = s.checkValue(errs, fmt.Sprintf("%s[%d]", path, i), typ, elem)
+		}
+		return errs
+	}
+	switch typ.Kind {
+	case "ENUM":
+		name, ok := value.(string)
+		if !ok {
+			if value != nil {
+				errs = append(errs, fmt.Errorf("graphql: variable %s: enum %s must be a string, not %v", path, typ.Name, value))
+			}
+			return errs
+		}
+		for _, v := range typ.EnumValues {
+			if v.Name == name {
+				return errs
+			}
+		}
+		errs = append(errs, fmt.Errorf("graphql: variable %s: %q is not a value of enum %s", path, name, typ.Name))
+	case "INPUT_OBJECT":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return errs
+		}
+		for _, field := range typ.InputFields {
+			if v, ok := obj[field.Name]; ok {
+				errs = s.checkValue(errs, path+"."+field.Name, s.Type(field.Type.NamedType()), v)
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,85 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type testStatus string
+
+func (s testStatus) EnumName() string   { return string(s) }
+func (testStatus) EnumValues() []string { return []string{"ACTIVE", "SOLD"} }
+
+func TestEnumVariables(t *testing.T) {
+	is := is.New(t)
+
+	var variables []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables json.RawMessage
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		variables = append(variables, string(body.Variables))
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL)
+	req := NewRequest("query ($status: Status, $filter: Filter, $any: [Status]) { items { id } }")
+	req.Var("status", testStatus("SOLD"))
+	req.Var("filter", map[string]interface{}{"statuses": []testStatus{"ACTIVE"}})
+	req.Var("any", []interface{}{testStatus("ACTIVE"), nil})
+	_, err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(variables, []string{`{"any":["ACTIVE",null],"filter":{"statuses":["ACTIVE"]},"status":"SOLD"}`})
+
+	req.Var("filter", map[string]interface{}{"statuses": []testStatus{"ACTIVE", "GONE"}})
+	_, err = client.Run(ctx, req, nil)
+	is.Equal(err.Error(), `graphql: variable $filter.statuses[1]: "GONE" is not a value of the enum`)
+	req.Var("filter", nil)
+	req.Var("any", []interface{}{testStatus("sold")})
+	_, err = client.Run(ctx, req, nil)
+	is.Equal(err.Error(), `graphql: variable $any[0]: "sold" is not a value of the enum`)
+	is.Equal(len(variables), 1) // invalid requests aren't sent
+}
+
+func TestSchemaValidateVariables(t *testing.T) {
+	is := is.New(t)
+
+	schema := testSchema()
+	schema.Types = append(schema.Types, SchemaType{Kind: "INPUT_OBJECT", Name: "Filter", InputFields: []SchemaInputValue{
+		{Name: "statuses", Type: list(named("ENUM", "Status"))},
+		{Name: "name", Type: named("SCALAR", "String")},
+	}})
+	q := `query ($status: Status!, $filter: Filter, $id: ID) { item(id: $id) { id } }`
+	is.Equal(schema.ValidateVariables(q, map[string]interface{}{
+		"status": "ACTIVE",
+		"filter": struct {
+			Statuses []string `json:"statuses"`
+		}{[]string{"SOLD"}},
+		"id": "1",
+	}), []error(nil))
+
+	errs := schema.ValidateVariables(q, map[string]interface{}{
+		"status": "active",
+		"filter": map[string]interface{}{"statuses": []string{"SOLD", "LOST"}, "name": "x"},
+		"extra":  "ignored",
+	})
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	is.Equal(messages, []string{
+		`graphql: variable $status: "active" is not a value of enum Status`,
+		`graphql: variable $filter.statuses[1]: "LOST" is not a value of enum Status`,
+	})
+}
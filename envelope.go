@@ -0,0 +1,124 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WithEnvelopeValidation checks that each successful response has the
+// shape the GraphQL spec gives responses, failing the request with an
+// EnvelopeError if not. Without it the client decodes what it can and
+// ignores the rest, which hides proxies and gateways that mangle
+// response bodies. A response must:
+//
+//   - be a JSON object with no keys other than data, errors and
+//     extensions, and at least one of data and errors
+//   - have data that is an object or null
+//   - have errors, if any, that are a non-empty list of objects, each
+//     with a string message
+//   - have extensions, if any, that are an object
+//   - not have data other than null alongside errors that all lack a
+//     path, as those are request errors raised before execution began
+func WithEnvelopeValidation() ClientOption {
+	return func(client *Client) {
+		client.validateEnvelope = true
+	}
+}
+
+// EnvelopeError is returned, when the client was created with
+// WithEnvelopeValidation, for a response that isn't shaped like a
+// GraphQL response.
+type EnvelopeError struct {
+	// Problems describes each way the response is malformed.
+	Problems []string
+	// Body is the start of the response body, up to
+	// WithMaxErrorBodySize.
+	Body []byte
+}
+
+func (e *EnvelopeError) Error() string {
+	return "graphql: malformed response: " + strings.Join(e.Problems, "; ")
+}
+
+// checkEnvelope returns an EnvelopeError if body isn't shaped like a
+// GraphQL response, or nil if it is.
+func (c *Client) checkEnvelope(body []byte) error {
+	problems := envelopeProblems(body)
+	if len(problems) == 0 {
+		return nil
+	}
+	if limit := c.errorBodyLimit(); int64(len(body)) > limit {
+		body = body[:limit]
+	}
+	return &EnvelopeError{Problems: problems, Body: body}
+}
+
+// envelopeProblems describes each way body is malformed. Bodies that
+// aren't JSON at all are left for decoding to report.
+func envelopeProblems(body []byte) []string {
+	if !json.Valid(body) {
+		return nil
+	}
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope == nil {
+		return []string{"not a JSON object"}
+	}
+	var problems []string
+	var unknown []string
+	for key := range envelope {
+		if key != "data" && key != "errors" && key != "extensions" {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, fmt.Sprintf("unexpected key %q", key))
+	}
+	data, hasData := envelope["data"]
+	rawErrors, hasErrors := envelope["errors"]
+	if !hasData && !hasErrors {
+		problems = append(problems, "neither data nor errors")
+	}
+	if hasData && !isJSONNull(data) && !isJSONObject(data) {
+		problems = append(problems, "data is not an object or null")
+	}
+	if extensions, ok := envelope["extensions"]; ok && !isJSONNull(extensions) && !isJSONObject(extensions) {
+		problems = append(problems, "extensions is not an object")
+	}
+	if !hasErrors {
+		return problems
+	}
+	var errs []map[string]json.RawMessage
+	if err := json.Unmarshal(rawErrors, &errs); err != nil || errs == nil {
+		return append(problems, "errors is not a list of objects")
+	}
+	if len(errs) == 0 {
+		return append(problems, "errors is empty")
+	}
+	withPath := false
+	for i, e := range errs {
+		var message string
+		if json.Unmarshal(e["message"], &message) != nil {
+			problems = append(problems, fmt.Sprintf("errors[%d] has no message", i))
+		}
+		if path, ok := e["path"]; ok && !isJSONNull(path) {
+			withPath = true
+		}
+	}
+	if !withPath && hasData && !isJSONNull(data) {
+		problems = append(problems, "data alongside request errors")
+	}
+	return problems
+}
+
+func isJSONNull(v json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(v), []byte("null"))
+}
+
+func isJSONObject(v json.RawMessage) bool {
+	v = bytes.TrimSpace(v)
+	return len(v) > 0 && v[0] == '{'
+}
@@ -0,0 +1,63 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWithEnvelopeValidation(t *testing.T) {
+	tests := []struct {
+		body     string
+		problems []string
+	}{
+		{body: `{"data":{"item":"some data"},"extensions":{"cost":1}}`},
+		{body: `{"data":null,"errors":[{"message":"failed","path":["item"]}]}`},
+		{body: `{"errors":[{"message":"syntax error"}]}`},
+		{body: `{"data":null,"errors":[{"message":"syntax error"}]}`},
+		{body: `{"message":"Bad gateway"}`, problems: []string{`unexpected key "message"`, "neither data nor errors"}},
+		{body: `[{"data":{}}]`, problems: []string{"not a JSON object"}},
+		{body: `{"data":[],"extensions":1}`, problems: []string{"data is not an object or null", "extensions is not an object"}},
+		{body: `{"data":null,"errors":"failed"}`, problems: []string{"errors is not a list of objects"}},
+		{body: `{"data":null,"errors":[]}`, problems: []string{"errors is empty"}},
+		{body: `{"data":null,"errors":[{"msg":"failed","path":["item"]}]}`, problems: []string{"errors[0] has no message"}},
+		{body: `{"data":{"item":null},"errors":[{"message":"syntax error"}]}`, problems: []string{"data alongside request errors"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			is := is.New(t)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			_, err := NewClient(srv.URL, WithEnvelopeValidation()).Run(context.Background(), NewRequest("query { item }"), nil)
+			var envelopeErr *EnvelopeError
+			if tt.problems == nil {
+				is.True(!errors.As(err, &envelopeErr))
+				return
+			}
+			is.True(errors.As(err, &envelopeErr))
+			is.Equal(envelopeErr.Problems, tt.problems)
+			is.Equal(string(envelopeErr.Body), tt.body)
+		})
+	}
+}
+
+func TestEnvelopeValidationLeavesDecodingErrors(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<html>Bad gateway</html>`)
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(srv.URL, WithEnvelopeValidation()).Run(context.Background(), NewRequest("query { item }"), nil)
+	var httpErr *HTTPError
+	is.True(errors.As(err, &httpErr))
+	is.True(httpErr.Err != nil)
+}
@@ -0,0 +1,87 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TransportError is returned when a request couldn't be sent or no
+// response was received, such as when the server can't be reached or
+// the request timed out.
+//
+// TransportError, HTTPError and ResponseError are the three kinds of
+// failure a request can meet, along with EnvelopeError for clients
+// created with WithEnvelopeValidation, and can be told apart with
+// errors.As:
+//
+//	var httpErr *graphql.HTTPError
+//	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized {
+//	    // refresh credentials
+//	}
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPError is returned when the server responded with a status the
+// client doesn't count as successful and no GraphQL errors explaining
+// why, or with a body that couldn't be decoded.
+type HTTPError struct {
+	StatusCode  int
+	ContentType string
+	// Body is the start of the response body, up to
+	// WithMaxErrorBodySize.
+	Body []byte
+	// Err is the error decoding the body, when the status was
+	// successful but the body wasn't a GraphQL response, as when a proxy
+	// answers with an HTML page.
+	Err error
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("decoding response (%s): %v", describeBody(e.ContentType, e.Body), e.Err)
+	}
+	return fmt.Sprintf("graphql: server returned a non-200 status code: %v (%s)", e.StatusCode, describeBody(e.ContentType, e.Body))
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// ResponseError is returned when the server responded with errors in
+// the errors field of the response. Like an error made by errors.Join,
+// its message has the message of each error on a line of its own, and
+// errors.As can match any of them:
+//
+//	var gqlErr graphql.GraphQLError
+//	if errors.As(err, &gqlErr) {
+//	    log.Println(gqlErr.Path)
+//	}
+type ResponseError struct {
+	Errors []GraphQLError
+}
+
+func (e *ResponseError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the individual errors.
+func (e *ResponseError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,84 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestErrorKinds(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/http":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, "<html>Bad Gateway</html>")
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<html>Maintenance</html>")
+		case "/graphql":
+			io.WriteString(w, `{"errors":[{"message":"not found","path":["item"],"extensions":{"code":"NOT_FOUND"}}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	_, err := NewClient(srv.URL+"/http").Run(ctx, NewRequest("query {}"), nil)
+	var httpErr *HTTPError
+	is.True(errors.As(err, &httpErr))
+	is.Equal(httpErr.StatusCode, http.StatusBadGateway)
+	is.Equal(httpErr.ContentType, "text/html")
+	is.Equal(string(httpErr.Body), "<html>Bad Gateway</html>")
+	is.Equal(err.Error(), `graphql: server returned a non-200 status code: 502 (content type "text/html", body "<html>Bad Gateway</html>")`)
+
+	// a successful status with a body that isn't GraphQL, such as a
+	// proxy's maintenance page
+	_, err = NewClient(srv.URL+"/html").Run(ctx, NewRequest("query {}"), nil)
+	httpErr = nil
+	is.True(errors.As(err, &httpErr))
+	is.Equal(httpErr.StatusCode, http.StatusOK)
+	is.Equal(string(httpErr.Body), "<html>Maintenance</html>")
+	var syntaxErr *json.SyntaxError
+	is.True(errors.As(err, &syntaxErr))
+
+	_, err = NewClient(srv.URL+"/graphql").Run(ctx, NewRequest("query {}"), nil)
+	var responseErr *ResponseError
+	is.True(errors.As(err, &responseErr))
+	is.Equal(responseErr.Errors[0].Path, []interface{}{"item"})
+	is.Equal(responseErr.Errors[0].Extensions["code"], "NOT_FOUND")
+	is.Equal(err.Error(), "graphql: not found")
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	_, err = NewClient(closed.URL).Run(ctx, NewRequest("query {}"), nil)
+	var transportErr *TransportError
+	is.True(errors.As(err, &transportErr))
+	is.True(!errors.As(err, &httpErr))
+}
+
+func TestResponseErrorJoined(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"errors":[{"message":"first","path":["a"]},{"message":"second","path":["b"]}]}`)
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(srv.URL).Run(context.Background(), NewRequest("query {}"), nil)
+	is.Equal(err.Error(), "graphql: first\ngraphql: second")
+	var gqlErr GraphQLError
+	is.True(errors.As(err, &gqlErr))
+	is.Equal(gqlErr.Message, "first")
+	var responseErr *ResponseError
+	is.True(errors.As(err, &responseErr))
+	is.Equal(len(responseErr.Unwrap()), 2)
+	is.Equal(responseErr.Unwrap()[1].(GraphQLError).Path, []interface{}{"b"})
+}
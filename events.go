@@ -0,0 +1,121 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// EventListener is notified of the lifecycle of the requests a Client
+// runs, so metrics and tracing can be plugged in without the client
+// depending on any particular system.
+//
+// Methods are called synchronously on the goroutine running the request
+// and so should return quickly. Embed NopEventListener to implement only
+// the methods you need.
+type EventListener interface {
+	// RequestStarted is called when Run is called.
+	RequestStarted(RequestStartedEvent)
+	// AttemptStarted is called just before each HTTP request is sent.
+	AttemptStarted(AttemptStartedEvent)
+	// AttemptDone is called once an HTTP request has completed or failed.
+	AttemptDone(AttemptDoneEvent)
+	// RetryScheduled is called when the client or a Queue is going to
+	// retry a request.
+	RetryScheduled(RetryScheduledEvent)
+	// RequestDone is called when Run returns.
+	RequestDone(RequestDoneEvent)
+}
+
+// RequestStartedEvent is passed to EventListener.RequestStarted.
+type RequestStartedEvent struct {
+	Context context.Context
+	Request *Request
+	// Operation is the name of the operation, as Request.OperationName
+	// gives it, for tagging metrics and traces.
+	Operation string
+}
+
+// AttemptStartedEvent is passed to EventListener.AttemptStarted.
+type AttemptStartedEvent struct {
+	Context     context.Context
+	HTTPRequest *http.Request
+}
+
+// AttemptDoneEvent is passed to EventListener.AttemptDone.
+type AttemptDoneEvent struct {
+	Context     context.Context
+	HTTPRequest *http.Request
+	Response    *http.Response
+	Err         error
+	Duration    time.Duration
+}
+
+// RetryScheduledEvent is passed to EventListener.RetryScheduled.
+type RetryScheduledEvent struct {
+	Request *Request
+	// Attempt is the number of the attempt that failed, starting at 1.
+	Attempt int
+	Err     error
+	// Delay is how long to wait before retrying.
+	Delay time.Duration
+}
+
+// RequestDoneEvent is passed to EventListener.RequestDone.
+type RequestDoneEvent struct {
+	Context context.Context
+	Request *Request
+	// Operation is the name of the operation, as Request.OperationName
+	// gives it.
+	Operation string
+	Response  *http.Response
+	Err       error
+	Duration  time.Duration
+}
+
+// NopEventListener is an EventListener that does nothing. It is intended
+// to be embedded in listeners that only care about some events.
+type NopEventListener struct{}
+
+// RequestStarted does nothing.
+func (NopEventListener) RequestStarted(RequestStartedEvent) {}
+
+// AttemptStarted does nothing.
+func (NopEventListener) AttemptStarted(AttemptStartedEvent) {}
+
+// AttemptDone does nothing.
+func (NopEventListener) AttemptDone(AttemptDoneEvent) {}
+
+// RetryScheduled does nothing.
+func (NopEventListener) RetryScheduled(RetryScheduledEvent) {}
+
+// RequestDone does nothing.
+func (NopEventListener) RequestDone(RequestDoneEvent) {}
+
+// WithEventListener sets an EventListener to be notified of the
+// lifecycle of each request.
+func WithEventListener(listener EventListener) ClientOption {
+	return func(client *Client) {
+		client.events = listener
+	}
+}
+
+// do sends the HTTP request, notifying the event listener.
+func (c *Client) do(ctx context.Context, r *http.Request) (*http.Response, error) {
+	c.events.AttemptStarted(AttemptStartedEvent{Context: ctx, HTTPRequest: r})
+	start := time.Now()
+	var (
+		res *http.Response
+		err error
+	)
+	if c.chaos != nil {
+		res, err = c.chaos.roundTrip(r, c.httpClient.Do)
+	} else {
+		res, err = c.httpClient.Do(r)
+	}
+	if err != nil {
+		err = &TransportError{Err: classifyTimeout(err)}
+	}
+	c.events.AttemptDone(AttemptDoneEvent{Context: ctx, HTTPRequest: r, Response: res, Err: err, Duration: time.Since(start)})
+	return res, err
+}
@@ -0,0 +1,73 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type recordingListener struct {
+	NopEventListener
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *recordingListener) record(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *recordingListener) RequestStarted(RequestStartedEvent) { l.record("RequestStarted") }
+func (l *recordingListener) AttemptStarted(AttemptStartedEvent) { l.record("AttemptStarted") }
+func (l *recordingListener) AttemptDone(AttemptDoneEvent)       { l.record("AttemptDone") }
+func (l *recordingListener) RetryScheduled(RetryScheduledEvent) { l.record("RetryScheduled") }
+func (l *recordingListener) RequestDone(RequestDoneEvent)       { l.record("RequestDone") }
+
+func TestEventListener(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			io.WriteString(w, `{"errors":[{"message":"try again"}]}`)
+			return
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	listener := &recordingListener{}
+	client := NewClient(srv.URL, WithEventListener(listener))
+	_, err := client.Run(ctx, NewRequest("query {}"), nil)
+	is.Equal(err.Error(), "graphql: try again")
+	is.Equal(listener.events, []string{"RequestStarted", "AttemptStarted", "AttemptDone", "RequestDone"})
+
+	listener.events = nil
+	retrying := client.With(WithRetryableErrors(func(GraphQLError) bool { return true }))
+	q := retrying.NewQueue(WithQueueRetries(2, func(int) time.Duration { return time.Millisecond }))
+	calls = 0
+	req := NewRequest("mutation {}")
+	req.Idempotent()
+	is.NoErr(q.Enqueue(req))
+	eventually(t, func() bool {
+		listener.mu.Lock()
+		defer listener.mu.Unlock()
+		return len(listener.events) == 9
+	})
+	q.Close()
+	is.Equal(listener.events, []string{
+		"RequestStarted", "AttemptStarted", "AttemptDone", "RequestDone",
+		"RetryScheduled",
+		"RequestStarted", "AttemptStarted", "AttemptDone", "RequestDone",
+	})
+}
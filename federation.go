@@ -0,0 +1,90 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EntityRepresentation identifies an entity to fetch from an Apollo
+// Federation subgraph: its type and the fields of one of its keys.
+type EntityRepresentation struct {
+	Typename string
+	// Key holds the key fields, such as {"upc": "1"}.
+	Key map[string]interface{}
+}
+
+// MarshalJSON encodes the representation as an _Any scalar: an object of
+// the key fields with the typename in __typename.
+func (r EntityRepresentation) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]interface{}, len(r.Key)+1)
+	for k, v := range r.Key {
+		obj[k] = v
+	}
+	obj["__typename"] = r.Typename
+	return json.Marshal(obj)
+}
+
+// NewEntitiesRequest makes a request for the _entities field that
+// Federation subgraphs expose to gateways. selections maps each entity
+// type to the fields to select on it:
+//
+//	req, err := graphql.NewEntitiesRequest(reps, map[string]string{
+//	    "Product": "upc name price",
+//	})
+//
+// It returns an error if a representation has a type with no selection.
+func NewEntitiesRequest(representations []EntityRepresentation, selections map[string]string) (*Request, error) {
+	for _, rep := range representations {
+		if _, ok := selections[rep.Typename]; !ok {
+			return nil, fmt.Errorf("graphql: no selection for entity type %q", rep.Typename)
+		}
+	}
+	types := make([]string, 0, len(selections))
+	for typename := range selections {
+		types = append(types, typename)
+	}
+	sort.Strings(types)
+	var q strings.Builder
+	q.WriteString("query ($representations: [_Any!]!) { _entities(representations: $representations) { __typename")
+	for _, typename := range types {
+		fmt.Fprintf(&q, " ... on %s { %s }", typename, selections[typename])
+	}
+	q.WriteString(" } }")
+	req := NewRequest(q.String())
+	req.Var("representations", representations)
+	return req, nil
+}
+
+// Entities fetches entities from a Federation subgraph, as a gateway
+// would when resolving a query across subgraphs, which helps when
+// debugging a subgraph or building a lightweight router. The request is
+// built by NewEntitiesRequest, and the entities are decoded into
+// entities, which should be a pointer to a slice; entities the subgraph
+// couldn't resolve are null, in the same position as their
+// representation, and the errors the subgraph gave for them are
+// returned in a ResponseError alongside the entities it did resolve.
+func (c *Client) Entities(ctx context.Context, representations []EntityRepresentation, selections map[string]string, entities interface{}) (*Response, error) {
+	req, err := NewEntitiesRequest(representations, selections)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Entities json.RawMessage `json:"_entities"`
+	}
+	res, err := c.Run(ctx, req, &resp)
+	var responseErr *ResponseError
+	if err != nil && !errors.As(err, &responseErr) {
+		return res, err
+	}
+	if entities != nil && len(resp.Entities) > 0 {
+		if err := c.decodeData(resp.Entities, entities); err != nil {
+			return res, errors.Wrap(err, "decoding entities")
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,87 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestEntities(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string
+			Variables map[string]json.RawMessage
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		is.Equal(body.Query, "query ($representations: [_Any!]!) { _entities(representations: $representations) { __typename ... on Product { upc name } ... on User { id } } }")
+		is.Equal(string(body.Variables["representations"]), `[{"__typename":"Product","upc":"1"},{"__typename":"User","id":"2"},{"__typename":"Product","upc":"3"}]`)
+		io.WriteString(w, `{"data":{"_entities":[
+			{"__typename":"Product","upc":"1","name":"Table"},
+			{"__typename":"User","id":"2"},
+			null
+		]}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL)
+	reps := []EntityRepresentation{
+		{Typename: "Product", Key: map[string]interface{}{"upc": "1"}},
+		{Typename: "User", Key: map[string]interface{}{"id": "2"}},
+		{Typename: "Product", Key: map[string]interface{}{"upc": "3"}},
+	}
+	var entities []*struct {
+		Typename string `json:"__typename"`
+		UPC      string
+		Name     string
+		ID       string
+	}
+	_, err := client.Entities(ctx, reps, map[string]string{"Product": "upc name", "User": "id"}, &entities)
+	is.NoErr(err)
+	is.Equal(len(entities), 3)
+	is.Equal(entities[0].Name, "Table")
+	is.Equal(entities[1].ID, "2")
+	is.True(entities[2] == nil) // not found
+
+	_, err = client.Entities(ctx, reps, map[string]string{"Product": "upc"}, &entities)
+	is.Equal(err.Error(), `graphql: no selection for entity type "User"`)
+}
+
+func TestEntitiesPartial(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"_entities":[
+			{"__typename":"Product","upc":"1","name":"Table"},
+			null
+		]},"errors":[{"message":"product 3 not found","path":["_entities",1]}]}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	reps := []EntityRepresentation{
+		{Typename: "Product", Key: map[string]interface{}{"upc": "1"}},
+		{Typename: "Product", Key: map[string]interface{}{"upc": "3"}},
+	}
+	var entities []*struct {
+		UPC  string
+		Name string
+	}
+	_, err := client.Entities(context.Background(), reps, map[string]string{"Product": "upc name"}, &entities)
+	var responseErr *ResponseError
+	is.True(errors.As(err, &responseErr))
+	is.Equal(responseErr.Errors[0].Message, "product 3 not found")
+	is.Equal(len(entities), 2) // decoded alongside the errors
+	is.Equal(entities[0].Name, "Table")
+	is.True(entities[1] == nil)
+}
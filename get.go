@@ -0,0 +1,132 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// Transport is the way a request is sent to the server.
+type Transport int
+
+const (
+	// TransportAuto sends requests with files as multipart form data and
+	// everything else as JSON, unless the client says otherwise.
+	TransportAuto Transport = iota
+	// TransportJSON sends the request as a JSON body.
+	TransportJSON
+	// TransportMultipart sends the request as multipart form data, which
+	// is needed for files.
+	TransportMultipart
+	// TransportGET sends queries as the query string of a GET request,
+	// which lets caches and CDNs store the response. Other operations,
+	// and queries too long for WithMaxURLLength, are sent as JSON.
+	// Files can't be sent with GET.
+	TransportGET
+)
+
+func (t Transport) String() string {
+	switch t {
+	case TransportAuto:
+		return "auto"
+	case TransportJSON:
+		return "JSON"
+	case TransportMultipart:
+		return "multipart"
+	case TransportGET:
+		return "GET"
+	}
+	return fmt.Sprintf("Transport(%d)", int(t))
+}
+
+// WithTransport sets the transport used for requests that don't choose
+// one of their own with Request.Transport.
+func WithTransport(t Transport) ClientOption {
+	return func(client *Client) {
+		client.defaultTransport = t
+	}
+}
+
+// Transport sets the transport used to send the request, overriding the
+// client's choice.
+func (req *Request) Transport(t Transport) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.transport = t
+}
+
+// transportFor works out the transport to send req with.
+func (c *Client) transportFor(req *Request) Transport {
+	t := req.transport
+	if t == TransportAuto {
+		t = c.defaultTransport
+	}
+	switch {
+	case t == TransportAuto:
+		t = TransportJSON
+		if c.useMultipartForm || len(req.formFields) > 0 || (len(req.files) > 0 && c.base64Uploads == 0) {
+			t = TransportMultipart
+		}
+	case t == TransportGET && len(req.files) == 0 && operationType(req.q) != "query":
+		// GET is only safe for queries
+		t = TransportJSON
+	}
+	return t
+}
+
+// runWithGET sends the request as the query string of a GET request,
+// falling back to a JSON POST if the URL would be too long. If pq is not
+// nil the request is sent as a persisted query.
+func (c *Client) runWithGET(ctx context.Context, req *Request, pq *persistedQuery, gr *graphResponse) (*http.Response, error) {
+	u, err := url.Parse(c.endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse endpoint")
+	}
+	params := u.Query()
+	if pq == nil || pq.withQuery {
+		params.Set("query", req.q)
+	}
+	if req.operation != "" {
+		params.Set("operationName", req.operation)
+	}
+	if len(req.vars) > 0 {
+		variables, err := json.Marshal(req.vars)
+		if err != nil {
+			return nil, errors.Wrap(err, "encode variables")
+		}
+		params.Set("variables", string(variables))
+	}
+	if pq != nil {
+		var extensions bytes.Buffer
+		pq.writeExtensions(&extensions)
+		params.Set("extensions", extensions.String())
+	}
+	u.RawQuery = params.Encode()
+	if c.maxURLLength > 0 && len(u.String()) > c.maxURLLength {
+		c.logf("GET URL too long, sending with POST")
+		return c.runWithJSON(ctx, req, pq, gr)
+	}
+	c.logf(">> variables: %v", req.vars)
+	c.logf(">> query: %s", req.q)
+	r, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	r.Close = c.closeReq
+	r.Header.Set("Accept", "application/json; charset=utf-8")
+	c.setHeaders(ctx, r, req)
+	c.logf(">> headers: %v", r.Header)
+	c.stats.bytesSent.Add(int64(len(u.RawQuery)))
+	r = r.WithContext(ctx)
+	res, err := c.do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	return c.decodeResponse(res, gr)
+}
@@ -0,0 +1,116 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestTransportSelection(t *testing.T) {
+	is := is.New(t)
+
+	var contentTypes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentTypes = append(contentTypes, r.Method+" "+strings.Split(r.Header.Get("Content-Type"), ";")[0])
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := NewClient(srv.URL)
+
+	_, err := client.Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	upload := NewRequest("mutation {}")
+	upload.File("file", "filename.txt", strings.NewReader("some file"))
+	_, err = client.Run(ctx, upload, nil)
+	is.NoErr(err)
+	multipart := NewRequest("query {}")
+	multipart.Transport(TransportMultipart)
+	_, err = client.Run(ctx, multipart, nil)
+	is.NoErr(err)
+	get := NewRequest("query {}")
+	get.Transport(TransportGET)
+	_, err = client.Run(ctx, get, nil)
+	is.NoErr(err)
+
+	is.Equal(contentTypes, []string{
+		"POST application/json",
+		"POST multipart/form-data",
+		"POST multipart/form-data",
+		"GET ",
+	})
+
+	_, err = NewClient(srv.URL, WithTransport(TransportJSON)).Run(ctx, upload.Clone(), nil)
+	is.Equal(err.Error(), "graphql: cannot send files with the JSON transport")
+
+	_, err = NewClientE(srv.URL, UseMultipartForm(), WithTransport(TransportGET))
+	is.True(err != nil) // conflicting transports
+}
+
+func TestDoGET(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Method == http.MethodPost {
+			io.WriteString(w, `{"data":{"item":"posted"}}`)
+			return
+		}
+		is.Equal(r.URL.Query().Get("key"), "abc")
+		is.Equal(r.URL.Query().Get("query"), "query ($id: ID!) { item(id: $id) }")
+		is.Equal(r.URL.Query().Get("variables"), `{"id":"123"}`)
+		io.WriteString(w, `{"data":{"item":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := NewClient(srv.URL+"?key=abc", WithTransport(TransportGET))
+	req := NewRequest("query ($id: ID!) { item(id: $id) }")
+	req.Var("id", "123")
+	var resp struct {
+		Item string
+	}
+	_, err := client.Run(ctx, req, &resp)
+	is.NoErr(err)
+	is.Equal(resp.Item, "some data")
+
+	_, err = client.Run(ctx, NewRequest("mutation { item }"), &resp)
+	is.NoErr(err)
+	is.Equal(resp.Item, "posted") // mutations are never sent with GET
+
+	long := NewRequest("query ($id: ID!) { item(id: $id) }")
+	long.Var("id", strings.Repeat("x", defaultMaxURLLength))
+	_, err = client.Run(ctx, long, &resp)
+	is.NoErr(err)
+	is.Equal(resp.Item, "posted") // too long for GET
+	is.Equal(calls, 3)
+}
+
+func TestGetMaxURLLength(t *testing.T) {
+	is := is.New(t)
+
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	req := NewRequest("query ($id: ID!) { item(id: $id) }")
+	req.Var("id", strings.Repeat("x", 4000))
+	_, err := NewClient(srv.URL, WithTransport(TransportGET)).Run(ctx, req, nil)
+	is.NoErr(err)
+	_, err = NewClient(srv.URL, WithTransport(TransportGET), WithMaxURLLength(1024)).Run(ctx, req, nil)
+	is.NoErr(err)
+	_, err = NewClient(srv.URL, WithTransport(TransportGET), WithMaxURLLength(0)).Run(ctx, req.WithVar("id", strings.Repeat("x", 10000)), nil)
+	is.NoErr(err)
+	is.Equal(methods, []string{http.MethodGet, http.MethodPost, http.MethodGet})
+}
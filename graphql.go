@@ -38,7 +38,12 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -48,10 +53,91 @@ type Client struct {
 	endpoint         string
 	httpClient       *http.Client
 	useMultipartForm bool
+	multipartOrder   MultipartOrder
+	// base64Uploads is the size limit for files sent as variables, or
+	// zero if they are sent as multipart form data.
+	base64Uploads    int64
+	defaultTransport Transport
+	persistedQueries bool
+	// persistedRegistry records the hashes the server has stored, if
+	// WithPersistedQueryRegistry is used.
+	persistedRegistry *PersistedQueryRegistry
+	maxURLLength      int
+	strictDecoding    bool
+	validateEnvelope  bool
+	useNumber         bool
+	keepRawBody       bool
+
+	// allowedOperations holds the hashes of the documents the client may
+	// send, or is nil to allow any.
+	allowedOperations map[string]bool
+
+	// transport and dialer are only set if options have configured a
+	// client owned transport.
+	transport *http.Transport
+	dialer    *net.Dialer
+	dial      func(ctx context.Context, network, addr string) (net.Conn, error)
+	dnsCache  *dnsCache
+	// transportShared is set on a derived client until it changes
+	// the transport it inherited.
+	transportShared bool
+
+	// runner executes requests in place of the client's own HTTP
+	// transports, if WithRunner is used.
+	runner     Runner
+	middleware []Middleware
 
 	// closeReq will close the request body immediately allowing for reuse of client
 	closeReq bool
 
+	runAllConcurrency int
+	runAllFailFast    bool
+
+	pingQuery string
+
+	// keepHeaders lists the response headers to keep, or is nil to keep
+	// them all.
+	keepHeaders []string
+
+	maxErrorBodySize  int64
+	errorBodyStatuses func(status int) bool
+	successStatuses   func(status int) bool
+
+	idempotencyHeader   string
+	autoIdempotencyKeys bool
+
+	maxAttempts     int
+	backoff         func(attempt int) time.Duration
+	retryableErrors func(err GraphQLError) bool
+
+	priorityHeader string
+	timeoutHeader  string
+
+	warningHandler  func(req *Request, w Warning)
+	rateLimitParser RateLimitParser
+	costParser      CostParser
+
+	// timeFormat and durationFormat convert time variables.
+	timeFormat     TimeFormat
+	durationFormat DurationFormat
+
+	// header is added to every request the client makes.
+	header         http.Header
+	contextHeaders []contextHeader
+	timeout        time.Duration
+
+	userAgent      string
+	acceptEncoding string
+	clientName     string
+	clientVersion  string
+
+	// state is shared with the resources that belong to the client, such
+	// as its queues, so they can be torn down by Close.
+	state  *clientState
+	stats  *clientStats
+	events EventListener
+	chaos  *Chaos
+
 	// Log is called with various debug information.
 	// To log to standard out, use:
 	//  client.Log = func(s string) { log.Println(s) }
@@ -62,17 +148,95 @@ type Client struct {
 func NewClient(endpoint string, opts ...ClientOption) *Client {
 	c := &Client{
 		endpoint: endpoint,
+		state:    &clientState{},
+		stats:    &clientStats{},
+		events:   NopEventListener{},
 		Log:      func(string) {},
+
+		maxURLLength:    defaultMaxURLLength,
+		rateLimitParser: ParseRateLimitHeaders,
+		costParser:      ParseCostExtension,
 	}
 	for _, optionFunc := range opts {
 		optionFunc(c)
 	}
+	c.useOwnTransport()
 	if c.httpClient == nil {
 		c.httpClient = http.DefaultClient
 	}
 	return c
 }
 
+// NewClientE is like NewClient but reports a misconfigured client at
+// construction time, rather than leaving it to fail at the first Run.
+// It returns an error if the endpoint is not an absolute http or https
+// URL, or if the options conflict with one another.
+func NewClientE(endpoint string, opts ...ClientOption) (*Client, error) {
+	c := NewClient(endpoint, opts...)
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validate checks the client's configuration.
+func (c *Client) validate() error {
+	u, err := url.Parse(c.endpoint)
+	if err != nil {
+		return errors.Wrap(err, "graphql: invalid endpoint")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("graphql: invalid endpoint %q: scheme must be http or https", c.endpoint)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("graphql: invalid endpoint %q: missing host", c.endpoint)
+	}
+	if c.transport != nil && c.httpClient.Transport != c.transport {
+		return errors.New("graphql: transport options have no effect on a client given its own http.Client with WithHTTPClient")
+	}
+	if c.useMultipartForm && c.defaultTransport != TransportAuto && c.defaultTransport != TransportMultipart {
+		return fmt.Errorf("graphql: UseMultipartForm conflicts with the %s transport", c.defaultTransport)
+	}
+	if c.runAllConcurrency < 0 {
+		return errors.New("graphql: RunAll concurrency must not be negative")
+	}
+	if c.maxErrorBodySize < 0 {
+		return errors.New("graphql: max error body size must not be negative")
+	}
+	return nil
+}
+
+// With returns a copy of the client with opts applied on top of the
+// options it already has. The copy shares the underlying http.Client
+// (and so its connection pool) with the original, making it cheap to
+// create variants for particular tenants or kinds of call:
+//
+//	admin := client.With(graphql.WithHeader("Authorization", adminToken))
+//
+// Queues and Stats belong to the client that created them, so closing
+// one client doesn't close the queues of the other.
+func (c *Client) With(opts ...ClientOption) *Client {
+	derived := *c
+	derived.header = c.header.Clone()
+	// options append to these, so the copy needs slices of its own
+	derived.contextHeaders = append([]contextHeader(nil), c.contextHeaders...)
+	if c.keepHeaders != nil {
+		derived.keepHeaders = append([]string{}, c.keepHeaders...)
+	}
+	derived.state = &clientState{}
+	derived.stats = &clientStats{}
+	derived.transportShared = true
+	for _, optionFunc := range opts {
+		optionFunc(&derived)
+	}
+	if derived.transport != c.transport {
+		// the options need a transport of the derived client's own
+		derived.httpClient = nil
+		derived.useOwnTransport()
+	}
+	return &derived
+}
+
 func (c *Client) logf(format string, args ...interface{}) {
 	c.Log(fmt.Sprintf(format, args...))
 }
@@ -80,148 +244,445 @@ func (c *Client) logf(format string, args ...interface{}) {
 // Run executes the query and unmarshals the response from the data field
 // into the response object.
 // Pass in a nil response object to skip response parsing.
-// If the request fails or the server returns an error, the first error
-// will be returned.
-func (c *Client) Run(ctx context.Context, req *Request, resp interface{}) (*http.Response, error) {
+// If the request fails the error is returned; if the server returns
+// errors they are all returned together in a ResponseError.
+//
+// The returned Response is nil if no response was received.
+func (c *Client) Run(ctx context.Context, req *Request, resp interface{}) (*Response, error) {
+	if !c.state.begin() {
+		return nil, ErrClientClosed
+	}
+	defer c.state.end()
+	return c.runRequest(ctx, req, resp)
+}
+
+// runRequest runs the request as Run does, without checking that the
+// client is accepting requests, so that queues can finish the requests
+// in them while the client shuts down.
+func (c *Client) runRequest(ctx context.Context, req *Request, resp interface{}) (*Response, error) {
+	if err := c.checkAllowed(req.Query()); err != nil {
+		return nil, err
+	}
+	gr := &graphResponse{
+		Data: resp,
+	}
+	res, err := c.send(ctx, req, gr)
+	if res == nil {
+		return nil, err
+	}
+	return &Response{
+		Response:   res,
+		RateLimit:  gr.rateLimit,
+		Duration:   gr.duration,
+		Attempts:   gr.attempts,
+		Endpoint:   servedBy(res),
+		Cached:     gr.cached,
+		body:       gr.body,
+		rawBody:    gr.rawBody,
+		useNumber:  c.useNumber,
+		costParser: c.costParser,
+	}, err
+}
+
+// send runs the request with retries, decoding the response into gr,
+// and does the bookkeeping around it: events, stats and captured
+// headers.
+func (c *Client) send(ctx context.Context, req *Request, gr *graphResponse) (*http.Response, error) {
+	start := time.Now()
+	operation := req.OperationName()
+	c.events.RequestStarted(RequestStartedEvent{Context: ctx, Request: req, Operation: operation})
+	res, err := c.runWithTimeout(ctx, req, gr)
+	c.reportWarnings(req, res, gr)
+	gr.rateLimit = c.parseRateLimit(res)
+	gr.cached = res != nil && fromCache(res.Header)
+	c.captureHeaders(res)
+	duration := time.Since(start)
+	gr.duration = duration
+	if err != ErrClientClosed {
+		c.stats.record(res, err, duration)
+	}
+	c.events.RequestDone(RequestDoneEvent{Context: ctx, Request: req, Operation: operation, Response: res, Err: err, Duration: duration})
+	if res != nil {
+		// the body has been read and closed already
+		res.Body = http.NoBody
+	}
+	return res, err
+}
+
+// runWithTimeout runs the request with retries, giving up on it once it
+// has taken longer than the timeout set for it, across every attempt.
+func (c *Client) runWithTimeout(ctx context.Context, req *Request, gr *graphResponse) (*http.Response, error) {
+	timeout := c.timeout
+	if t := req.getTimeout(); t > 0 {
+		timeout = t
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return c.runWithRetries(ctx, req, gr)
+}
+
+// runAttempt makes a single attempt at running the request, decoding
+// the response into gr.
+func (c *Client) runAttempt(ctx context.Context, req *Request, gr *graphResponse) (*http.Response, error) {
+	gr.Errors, gr.body, gr.rawBody = nil, nil, nil
+	res, err := c.run(ctx, req, gr)
+	if err == nil && len(gr.Errors) > 0 {
+		err = &ResponseError{Errors: gr.Errors}
+	}
+	if err != nil {
+		return res, c.throttled(res, gr, err)
+	}
+	return res, nil
+}
+
+// run executes the request and decodes the response into gr, leaving
+// any GraphQL errors in gr for the caller to inspect.
+func (c *Client) run(ctx context.Context, req *Request, gr *graphResponse) (*http.Response, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	default:
 	}
-	if len(req.files) > 0 && !c.useMultipartForm {
-		return nil, errors.New("cannot send files with PostFields option")
+	if c.state.isClosed() {
+		return nil, ErrClientClosed
+	}
+	req = c.withIdempotencyKey(req.snapshot())
+	if req.operation == "" {
+		req.operation = operationName(req.q)
 	}
-	if c.useMultipartForm {
-		return c.runWithPostFields(ctx, req, resp)
+	if req.operation != "" {
+		c.logf(">> operation: %s", req.operation)
 	}
-	return c.runWithJSON(ctx, req, resp)
+	vars, err := c.prepareVariables(req)
+	if err != nil {
+		return nil, err
+	}
+	req.vars = vars
+	if len(c.middleware) > 0 || len(req.middleware) > 0 {
+		return c.runWithMiddleware(ctx, req, gr)
+	}
+	return c.dispatch(ctx, req, gr)
 }
 
-func (c *Client) runWithJSON(ctx context.Context, req *Request, resp interface{}) (*http.Response, error) {
-	var requestBody bytes.Buffer
-	requestBodyObj := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables"`
-	}{
-		Query:     req.q,
-		Variables: req.vars,
+// dispatch sends req, a snapshot ready to go, with the transport or
+// Runner it calls for, decoding the response into gr.
+func (c *Client) dispatch(ctx context.Context, req *Request, gr *graphResponse) (*http.Response, error) {
+	if c.runner != nil {
+		return c.runWithRunner(ctx, req, gr)
+	}
+	transport := c.transportFor(req)
+	if len(req.formFields) > 0 && transport != TransportMultipart {
+		return nil, fmt.Errorf("graphql: cannot send form fields with the %s transport", transport)
+	}
+	if len(req.files) > 0 && transport != TransportMultipart {
+		if c.base64Uploads == 0 {
+			return nil, fmt.Errorf("graphql: cannot send files with the %s transport", transport)
+		}
+		if err := c.filesToVariables(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case transport == TransportMultipart:
+		return c.runWithPostFields(ctx, req, gr)
+	case req.prime:
+		return c.runFullPersisted(ctx, req, hashDocument(req.q), gr)
+	case c.persistedQueries:
+		return c.runPersisted(ctx, req, transport, gr)
+	case transport == TransportGET:
+		return c.runWithGET(ctx, req, nil, gr)
 	}
-	if err := json.NewEncoder(&requestBody).Encode(requestBodyObj); err != nil {
+	return c.runWithJSON(ctx, req, nil, gr)
+}
+
+// runWithJSON sends the request as a JSON body. If pq is not nil it is
+// sent as a persisted query, leaving out the query unless pq says
+// otherwise.
+func (c *Client) runWithJSON(ctx context.Context, req *Request, pq *persistedQuery, gr *graphResponse) (*http.Response, error) {
+	body, release, err := gr.bodies.json(req, pq)
+	if err != nil {
 		return nil, errors.Wrap(err, "encode body")
 	}
+	defer release()
 	c.logf(">> variables: %v", req.vars)
 	c.logf(">> query: %s", req.q)
-	gr := &graphResponse{
-		Data: resp,
-	}
-	r, err := http.NewRequest(http.MethodPost, c.endpoint, &requestBody)
+	r, err := body.newRequest(http.MethodPost, c.endpoint)
 	if err != nil {
 		return nil, err
 	}
 	r.Close = c.closeReq
 	r.Header.Set("Content-Type", "application/json; charset=utf-8")
 	r.Header.Set("Accept", "application/json; charset=utf-8")
-	for key, values := range req.Header {
-		for _, value := range values {
-			r.Header.Add(key, value)
-		}
-	}
+	c.setHeaders(ctx, r, req)
 	c.logf(">> headers: %v", r.Header)
+	c.stats.bytesSent.Add(r.ContentLength)
 	r = r.WithContext(ctx)
-	res, err := c.httpClient.Do(r)
+	res, err := c.do(ctx, r)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, res.Body); err != nil {
-		return nil, errors.Wrap(err, "reading body")
+	return c.decodeResponse(res, gr)
+}
+
+func (c *Client) runWithPostFields(ctx context.Context, req *Request, gr *graphResponse) (*http.Response, error) {
+	requestBody := getBuffer()
+	contentType, err := c.writePostFields(ctx, requestBody, req)
+	if err != nil {
+		putBuffer(requestBody)
+		return nil, err
 	}
-	c.logf("<< %s", buf.String())
-	if err := json.NewDecoder(&buf).Decode(&gr); err != nil {
-		if res.StatusCode != http.StatusOK {
-			return res, fmt.Errorf("graphql: server returned a non-200 status code: %v", res.StatusCode)
-		}
-		return res, errors.Wrap(err, "decoding response")
+	body := newPooledBody(requestBody)
+	defer body.release()
+	r, err := body.newRequest(http.MethodPost, c.endpoint)
+	if err != nil {
+		return nil, err
 	}
-	if len(gr.Errors) > 0 {
-		// return first error
-		return res, gr.Errors[0]
+	r.Close = c.closeReq
+	r.Header.Set("Content-Type", contentType)
+	r.Header.Set("Accept", "application/json; charset=utf-8")
+	c.setHeaders(ctx, r, req)
+	c.logf(">> headers: %v", r.Header)
+	c.stats.bytesSent.Add(r.ContentLength)
+	r = r.WithContext(ctx)
+	res, err := c.do(ctx, r)
+	if err != nil {
+		return nil, err
 	}
-	return res, nil
+	defer res.Body.Close()
+	return c.decodeResponse(res, gr)
 }
 
-func (c *Client) runWithPostFields(ctx context.Context, req *Request, resp interface{}) (*http.Response, error) {
-	var requestBody bytes.Buffer
-	writer := multipart.NewWriter(&requestBody)
+// writePostFields writes req into requestBody as multipart form data,
+// returning its content type.
+func (c *Client) writePostFields(ctx context.Context, requestBody *bytes.Buffer, req *Request) (string, error) {
+	writer := multipart.NewWriter(requestBody)
+	if c.multipartOrder == MultipartFilesFirst {
+		if err := writeFileParts(ctx, writer, req); err != nil {
+			return "", err
+		}
+	}
 	if err := writer.WriteField("query", req.q); err != nil {
-		return nil, errors.Wrap(err, "write query field")
+		return "", errors.Wrap(err, "write query field")
 	}
-	var variablesBuf bytes.Buffer
+	if req.operation != "" {
+		if err := writer.WriteField("operationName", req.operation); err != nil {
+			return "", errors.Wrap(err, "write operationName field")
+		}
+	}
+	var variables []byte
 	if len(req.vars) > 0 {
 		variablesField, err := writer.CreateFormField("variables")
 		if err != nil {
-			return nil, errors.Wrap(err, "create variables field")
+			return "", errors.Wrap(err, "create variables field")
 		}
-		if err := json.NewEncoder(io.MultiWriter(variablesField, &variablesBuf)).Encode(req.vars); err != nil {
-			return nil, errors.Wrap(err, "encode variables")
+		start := requestBody.Len()
+		if err := json.NewEncoder(variablesField).Encode(req.vars); err != nil {
+			return "", errors.Wrap(err, "encode variables")
 		}
+		variables = requestBody.Bytes()[start:]
 	}
-	for i := range req.files {
-		part, err := writer.CreateFormFile(req.files[i].Field, req.files[i].Name)
-		if err != nil {
-			return nil, errors.Wrap(err, "create form file")
+	c.logf(">> variables: %s", variables)
+	for _, f := range req.formFields {
+		if err := writer.WriteField(f.name, f.value); err != nil {
+			return "", errors.Wrap(err, "write form field")
 		}
-		if _, err := io.Copy(part, req.files[i].R); err != nil {
-			return nil, errors.Wrap(err, "preparing file")
+	}
+	if c.multipartOrder != MultipartFilesFirst {
+		if err := writeFileParts(ctx, writer, req); err != nil {
+			return "", err
 		}
 	}
 	if err := writer.Close(); err != nil {
-		return nil, errors.Wrap(err, "close writer")
+		return "", errors.Wrap(err, "close writer")
 	}
-	c.logf(">> variables: %s", variablesBuf.String())
 	c.logf(">> files: %d", len(req.files))
 	c.logf(">> query: %s", req.q)
-	gr := &graphResponse{
-		Data: resp,
+	return writer.FormDataContentType(), nil
+}
+
+// writeFileParts writes a part for each of the request's files, in the
+// order they were added.
+func writeFileParts(ctx context.Context, writer *multipart.Writer, req *Request) error {
+	for i := range req.files {
+		part, err := writer.CreateFormFile(req.files[i].Field, req.files[i].Name)
+		if err != nil {
+			return errors.Wrap(err, "create form file")
+		}
+		if _, err := io.Copy(part, &contextReader{ctx: ctx, r: req.files[i].R}); err != nil {
+			return errors.Wrap(err, "preparing file")
+		}
 	}
-	r, err := http.NewRequest(http.MethodPost, c.endpoint, &requestBody)
-	if err != nil {
-		return nil, err
+	return nil
+}
+
+// defaultMaxErrorBodySize is the most of an error response body that is
+// read unless WithMaxErrorBodySize says otherwise.
+const defaultMaxErrorBodySize = 64 << 10
+
+// setHeaders sets the headers of the HTTP request r for req.
+func (c *Client) setHeaders(ctx context.Context, r *http.Request, req *Request) {
+	if c.userAgent != "" {
+		r.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", c.acceptEncoding)
+	}
+	if c.clientName != "" {
+		r.Header.Set("apollographql-client-name", c.clientName)
+	}
+	if c.clientVersion != "" {
+		r.Header.Set("apollographql-client-version", c.clientVersion)
+	}
+	for key, values := range c.header {
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+	for _, h := range c.contextHeaders {
+		if _, ok := req.Header[http.CanonicalHeaderKey(h.header)]; ok {
+			continue
+		}
+		if value := ctx.Value(h.key); value != nil {
+			r.Header.Set(h.header, fmt.Sprint(value))
+		}
 	}
-	r.Close = c.closeReq
-	r.Header.Set("Content-Type", writer.FormDataContentType())
-	r.Header.Set("Accept", "application/json; charset=utf-8")
 	for key, values := range req.Header {
 		for _, value := range values {
 			r.Header.Add(key, value)
 		}
 	}
-	c.logf(">> headers: %v", r.Header)
-	r = r.WithContext(ctx)
-	res, err := c.httpClient.Do(r)
-	if err != nil {
-		return nil, err
+	if req.idempotencyKey != "" {
+		r.Header.Set(c.idempotencyKeyHeader(), req.idempotencyKey)
 	}
-	defer res.Body.Close()
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, res.Body); err != nil {
+	if c.priorityHeader != "" {
+		r.Header.Set(c.priorityHeader, strconv.Itoa(int(req.priority)))
+	}
+	if deadline, ok := ctx.Deadline(); ok && c.timeoutHeader != "" {
+		remaining := time.Until(deadline).Milliseconds()
+		if remaining < 0 {
+			remaining = 0
+		}
+		r.Header.Set(c.timeoutHeader, strconv.FormatInt(remaining, 10))
+	}
+}
+
+// decodeResponse reads the response body and decodes it into gr.
+// The body of an error response is read only up to the client's limit,
+// the rest being discarded when the body is closed.
+func (c *Client) decodeResponse(res *http.Response, gr *graphResponse) (*http.Response, error) {
+	var body io.Reader = res.Body
+	limit := int64(-1)
+	if !c.isSuccess(res.StatusCode) {
+		limit = c.errorBodyLimit()
+		body = io.LimitReader(res.Body, limit)
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := io.Copy(buf, body); err != nil {
 		return nil, errors.Wrap(err, "reading body")
 	}
-	c.logf("<< %s", buf.String())
-	if err := json.NewDecoder(&buf).Decode(&gr); err != nil {
-		if res.StatusCode != http.StatusOK {
-			return res, fmt.Errorf("graphql: server returned a non-200 status code: %v", res.StatusCode)
+	c.stats.bytesReceived.Add(int64(buf.Len()))
+	// the body outlives the pooled buffer, in the Response, so it gets a
+	// copy of its own of exactly the right size
+	raw := append([]byte(nil), buf.Bytes()...)
+	if c.keepRawBody {
+		gr.rawBody = raw
+	}
+	if !res.Uncompressed && res.Header.Get("Content-Encoding") != "" {
+		// the client asked for the compressed body itself, so the
+		// transport left it alone
+		decoded, err := decompress(res.Header.Get("Content-Encoding"), raw, limit)
+		if err != nil {
+			return res, errors.Wrap(err, "decompressing body")
 		}
-		return res, errors.Wrap(err, "decoding response")
+		raw = decoded
 	}
-	if len(gr.Errors) > 0 {
-		// return first error
-		return res, gr.Errors[0]
+	c.logf("<< %s", raw)
+	return c.decodeBody(res, raw, gr)
+}
+
+// decodeBody decodes raw, the body of res, into gr.
+func (c *Client) decodeBody(res *http.Response, raw []byte, gr *graphResponse) (*http.Response, error) {
+	gr.body = raw
+	if c.isSuccess(res.StatusCode) && len(bytes.TrimSpace(raw)) == 0 {
+		// such as 204 No Content, or 202 Accepted for a queued mutation
+		return res, nil
+	}
+	if !c.isSuccess(res.StatusCode) && c.errorBodyStatuses != nil && !c.errorBodyStatuses(res.StatusCode) {
+		return res, statusError(res, raw)
+	}
+	if c.validateEnvelope && c.isSuccess(res.StatusCode) {
+		if err := c.checkEnvelope(raw); err != nil {
+			return res, err
+		}
+	}
+	// the data is decoded separately so the options for decoding it don't
+	// apply to the rest of the envelope
+	target := gr.Data
+	var data json.RawMessage
+	gr.Data = &data
+	err := json.NewDecoder(bytes.NewReader(raw)).Decode(&gr)
+	gr.Data = target
+	if err != nil {
+		if !c.isSuccess(res.StatusCode) {
+			return res, statusError(res, raw)
+		}
+		if limit := c.errorBodyLimit(); int64(len(raw)) > limit {
+			raw = raw[:limit]
+		}
+		return res, &HTTPError{
+			StatusCode:  res.StatusCode,
+			ContentType: res.Header.Get("Content-Type"),
+			Body:        raw,
+			Err:         err,
+		}
+	}
+	if !c.isSuccess(res.StatusCode) && len(gr.Errors) == 0 {
+		// a failed request should at least say why
+		return res, statusError(res, raw)
+	}
+	if target != nil && len(data) > 0 {
+		if err := c.decodeData(data, target); err != nil {
+			return res, errors.Wrap(err, "decoding response")
+		}
 	}
 	return res, nil
 }
 
+// errorBodyLimit returns how much of an error response body to read.
+func (c *Client) errorBodyLimit() int64 {
+	if c.maxErrorBodySize <= 0 {
+		return defaultMaxErrorBodySize
+	}
+	return c.maxErrorBodySize
+}
+
+func statusError(res *http.Response, body []byte) error {
+	return &HTTPError{
+		StatusCode:  res.StatusCode,
+		ContentType: res.Header.Get("Content-Type"),
+		Body:        body,
+	}
+}
+
+// maxBodySnippet is the most of a response body included in an error.
+const maxBodySnippet = 512
+
+// describeBody describes a response body that couldn't be decoded, so
+// errors show what actually answered the request: often a proxy or load
+// balancer error page rather than the GraphQL server.
+func describeBody(contentType string, body []byte) string {
+	snippet := string(body)
+	if len(body) > maxBodySnippet {
+		snippet = string(body[:maxBodySnippet]) + "..."
+	}
+	return fmt.Sprintf("content type %q, body %q", contentType, snippet)
+}
+
 // WithHTTPClient specifies the underlying http.Client to use when
 // making requests.
 //
@@ -232,14 +693,152 @@ func WithHTTPClient(httpclient *http.Client) ClientOption {
 	}
 }
 
-// UseMultipartForm uses multipart/form-data and activates support for
-// files.
+// UseMultipartForm uses multipart/form-data for every request, not just
+// those with files.
 func UseMultipartForm() ClientOption {
 	return func(client *Client) {
 		client.useMultipartForm = true
 	}
 }
 
+// MultipartOrder is the order of the parts of a multipart request.
+type MultipartOrder int
+
+const (
+	// MultipartFieldsFirst sends the query and variables fields before
+	// the files, as servers that stream uploads need, since they must
+	// know the operation before the files arrive.
+	MultipartFieldsFirst MultipartOrder = iota
+	// MultipartFilesFirst sends the files before the query and variables
+	// fields.
+	MultipartFilesFirst
+)
+
+// WithMultipartOrder sets the order of the parts of multipart requests.
+// Whichever order is chosen, the query field always comes before the
+// variables field and then any fields added with Request.FormField,
+// and files come in the order they were added with Request.File, so the
+// same request always produces the same parts.
+// The default is MultipartFieldsFirst.
+func WithMultipartOrder(order MultipartOrder) ClientOption {
+	return func(client *Client) {
+		client.multipartOrder = order
+	}
+}
+
+// WithHeader adds a header that is sent with every request the client
+// makes, in addition to those set on the Request.
+func WithHeader(key, value string) ClientOption {
+	return func(client *Client) {
+		if client.header == nil {
+			client.header = make(http.Header)
+		}
+		client.header.Add(key, value)
+	}
+}
+
+// WithHeaderFromContext sends the value stored in the request context
+// under key, if there is one, in the named header. This forwards values
+// like tenant IDs or locales without threading them through every call
+// site:
+//
+//	client := graphql.NewClient(endpoint, graphql.WithHeaderFromContext("X-Tenant-Id", tenantKey))
+//	ctx = context.WithValue(ctx, tenantKey, "acme")
+//
+// The value is formatted with fmt.Sprint. Headers set on the Request
+// itself take precedence.
+func WithHeaderFromContext(header string, key interface{}) ClientOption {
+	return func(client *Client) {
+		client.contextHeaders = append(client.contextHeaders, contextHeader{header: header, key: key})
+	}
+}
+
+// contextHeader is a header whose value comes from the request context.
+type contextHeader struct {
+	header string
+	key    interface{}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(client *Client) {
+		client.userAgent = userAgent
+	}
+}
+
+// WithClientAwareness identifies the calling service to the server with
+// the apollographql-client-name and apollographql-client-version
+// headers, which Apollo and compatible servers use to attribute traffic
+// in their analytics.
+func WithClientAwareness(name, version string) ClientOption {
+	return func(client *Client) {
+		client.clientName = name
+		client.clientVersion = version
+	}
+}
+
+// WithTimeout sets a limit on how long each request may take, on top of
+// any deadline on the context passed to Run. The limit covers every
+// attempt WithRetry makes and the waits between them, so a retry that
+// couldn't finish in the time left isn't made. Request.WithTimeout
+// overrides it for a particular request.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(client *Client) {
+		client.timeout = timeout
+	}
+}
+
+// WithTimeoutHeader sends the time left before the request's deadline,
+// from its context or WithTimeout, as a whole number of milliseconds in
+// the named header, such as X-Request-Timeout-Ms. Servers that honor it
+// can give up on work whose result the client would never see. Requests
+// without a deadline are sent without the header.
+func WithTimeoutHeader(name string) ClientOption {
+	return func(client *Client) {
+		client.timeoutHeader = name
+	}
+}
+
+// WithMaxErrorBodySize sets the most of the body of an error response
+// (one without a success status) that the client will read, so a
+// server streaming a huge error page can't exhaust memory. The rest of
+// the body is discarded. The default is 64KB.
+func WithMaxErrorBodySize(n int64) ClientOption {
+	return func(client *Client) {
+		client.maxErrorBodySize = n
+	}
+}
+
+// WithErrorBodyStatuses sets which error statuses (those that aren't
+// success statuses) have their body decoded for GraphQL errors. Errors
+// found in the body are returned in preference to a generic error about
+// the status. By default every error response body is decoded, since
+// 4xx and some gateways' 5xx responses carry well formed GraphQL errors.
+//
+//	// only trust error bodies from client errors
+//	WithErrorBodyStatuses(func(status int) bool { return status < 500 })
+func WithErrorBodyStatuses(decode func(status int) bool) ClientOption {
+	return func(client *Client) {
+		client.errorBodyStatuses = decode
+	}
+}
+
+// WithSuccessStatuses sets which HTTP statuses mean the request
+// succeeded. By default any 2xx status does. A successful response with
+// an empty body, such as 204 No Content, is not an error.
+func WithSuccessStatuses(success func(status int) bool) ClientOption {
+	return func(client *Client) {
+		client.successStatuses = success
+	}
+}
+
+func (c *Client) isSuccess(status int) bool {
+	if c.successStatuses != nil {
+		return c.successStatuses(status)
+	}
+	return status >= 200 && status < 300
+}
+
 // ImmediatelyCloseReqBody will close the req body immediately after each request body is ready
 func ImmediatelyCloseReqBody() ClientOption {
 	return func(client *Client) {
@@ -251,24 +850,73 @@ func ImmediatelyCloseReqBody() ClientOption {
 // modify the behaviour of the Client.
 type ClientOption func(*Client)
 
-type graphErr struct {
+// GraphQLError is an error returned by the server in the errors field
+// of a response.
+type GraphQLError struct {
 	Message string
+	// Path is the path to the field that failed, if any, made up of
+	// field names and list indexes.
+	Path       []interface{}
+	Extensions map[string]interface{}
 }
 
-func (e graphErr) Error() string {
+func (e GraphQLError) Error() string {
 	return "graphql: " + e.Message
 }
 
 type graphResponse struct {
 	Data   interface{}
-	Errors []graphErr
+	Errors []GraphQLError
+	// body is the response body the rest was decoded from.
+	body []byte
+	// rawBody is the body as it was received, if the client keeps it.
+	rawBody []byte
+	// bodies holds the request bodies built for the attempts so far.
+	bodies *requestBodies
+	// rateLimit is the rate limit the final response reported.
+	rateLimit *RateLimit
+	// attempts, duration and cached describe how the response was got,
+	// for the Response.
+	attempts int
+	duration time.Duration
+	cached   bool
 }
 
 // Request is a GraphQL request.
+//
+// A Request may be run any number of times, including from multiple
+// goroutines at once: each run works from a copy of the request taken
+// when it starts, so setting variables or files concurrently with a run
+// is safe and doesn't affect runs already in progress.
+// The Header map is accessed directly and so must not be modified while
+// the request is running.
+// File readers are consumed by the run that sends them, so a request
+// with files can't usefully be run more than once.
 type Request struct {
+	mu    sync.Mutex
 	q     string
 	vars  map[string]interface{}
 	files []File
+	// operation is the name of the operation to run, if set with
+	// Operation.
+	operation  string
+	formFields []formField
+	middleware []Middleware
+
+	idempotencyKey string
+	// generatedKey is set if idempotencyKey was generated by
+	// WithAutoIdempotencyKeys rather than set by the caller.
+	generatedKey   bool
+	idempotent     bool
+	priority       Priority
+	transport      Transport
+	uploadAbort    func(err error)
+	timeout        time.Duration
+	timeFormat     TimeFormat
+	durationFormat DurationFormat
+	// prime is set by Preconnect to send the query in full along with
+	// its hash.
+	prime bool
 
 	// Header represent any request headers that will be set
 	// when the request is made.
@@ -286,20 +934,33 @@ func NewRequest(q string) *Request {
 
 // Var sets a variable.
 func (req *Request) Var(key string, value interface{}) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
 	if req.vars == nil {
 		req.vars = make(map[string]interface{})
 	}
 	req.vars[key] = value
 }
 
-// Vars gets the variables for this Request.
+// Vars gets a copy of the variables for this Request.
 func (req *Request) Vars() map[string]interface{} {
-	return req.vars
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	if req.vars == nil {
+		return nil
+	}
+	vars := make(map[string]interface{}, len(req.vars))
+	for k, v := range req.vars {
+		vars[k] = v
+	}
+	return vars
 }
 
-// Files gets the files in this request.
+// Files gets a copy of the list of files in this request.
 func (req *Request) Files() []File {
-	return req.files
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	return append([]File(nil), req.files...)
 }
 
 // Query gets the query string of this request.
@@ -307,10 +968,39 @@ func (req *Request) Query() string {
 	return req.q
 }
 
-// File sets a file to upload.
-// Files are only supported with a Client that was created with
-// the UseMultipartForm option.
+// Operation sets the name of the operation in the document to run, which
+// is needed when the document has more than one.
+func (req *Request) Operation(name string) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.operation = name
+}
+
+// OperationName returns the name of the operation the request runs: the
+// one set with Operation, or else the name of the document's only
+// operation. It is sent to the server as operationName, and passed to
+// event listeners, so the request can be told apart in logs, metrics
+// and traces without the caller naming it. It returns an empty string
+// if the operation is anonymous.
+func (req *Request) OperationName() string {
+	req.mu.Lock()
+	name := req.operation
+	req.mu.Unlock()
+	if name != "" {
+		return name
+	}
+	return operationName(req.q)
+}
+
+// File sets a file to upload. Requests with files are sent as multipart
+// form data, unless another transport is chosen with WithTransport or
+// Request.Transport, in which case running them fails, or the client
+// sends files as variables with WithBase64Uploads. If the context
+// is done while r is being read no more of it is read; see
+// OnUploadAbort.
 func (req *Request) File(fieldname, filename string, r io.Reader) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
 	req.files = append(req.files, File{
 		Field: fieldname,
 		Name:  filename,
@@ -318,6 +1008,117 @@ func (req *Request) File(fieldname, filename string, r io.Reader) {
 	})
 }
 
+// FormField adds a form field to send alongside the query and variables,
+// for servers that need fields of their own, such as an access token.
+// Requests with form fields are sent as multipart form data, like those
+// with files, unless another transport is chosen with WithTransport or
+// Request.Transport, in which case running them fails. Fields are sent
+// after the variables, in the order they were added.
+func (req *Request) FormField(name, value string) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.formFields = append(req.formFields, formField{name: name, value: value})
+}
+
+// formField is an extra field of a multipart request.
+type formField struct {
+	name, value string
+}
+
+// Clone returns a copy of the request, so a template request can be
+// built once and specialised for each call. Variables and headers are
+// copied; files are shared, and their readers can still only be
+// consumed once.
+func (req *Request) Clone() *Request {
+	return req.snapshot()
+}
+
+// CloneWithVars returns a copy of the request, as Clone does, with vars
+// set on top of the variables it already has.
+func (req *Request) CloneWithVars(vars map[string]interface{}) *Request {
+	clone := req.snapshot()
+	for key, value := range vars {
+		clone.Var(key, value)
+	}
+	return clone
+}
+
+// snapshot returns a copy of the request that later changes to req
+// won't affect.
+func (req *Request) snapshot() *Request {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	snap := &Request{
+		q:              req.q,
+		operation:      req.operation,
+		files:          append([]File(nil), req.files...),
+		formFields:     append([]formField(nil), req.formFields...),
+		middleware:     req.middleware,
+		idempotencyKey: req.idempotencyKey,
+		generatedKey:   req.generatedKey,
+		idempotent:     req.idempotent,
+		priority:       req.priority,
+		transport:      req.transport,
+		uploadAbort:    req.uploadAbort,
+		timeout:        req.timeout,
+		timeFormat:     req.timeFormat,
+		durationFormat: req.durationFormat,
+		prime:          req.prime,
+		Header:         req.Header.Clone(),
+	}
+	if req.vars != nil {
+		snap.vars = make(map[string]interface{}, len(req.vars))
+		for key, value := range req.vars {
+			snap.vars[key] = value
+		}
+	}
+	return snap
+}
+
+// WithVar sets a variable and returns the request, so requests can be
+// built up in a single expression:
+//
+//	req := graphql.NewRequest(q).WithVar("id", 1).WithHeader("X-Trace", "abc")
+func (req *Request) WithVar(key string, value interface{}) *Request {
+	req.Var(key, value)
+	return req
+}
+
+// WithHeader adds a header and returns the request.
+func (req *Request) WithHeader(key, value string) *Request {
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header.Add(key, value)
+	return req
+}
+
+// WithFiles adds files to upload and returns the request.
+func (req *Request) WithFiles(files ...File) *Request {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.files = append(req.files, files...)
+	return req
+}
+
+// WithTimeout sets a limit on how long the request may take, retries
+// included, in place of the client's WithTimeout, and returns the
+// request. It lets slow
+// operations such as reports and exports be given longer than the
+// client default.
+func (req *Request) WithTimeout(timeout time.Duration) *Request {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.timeout = timeout
+	return req
+}
+
+func (req *Request) getTimeout() time.Duration {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	return req.timeout
+}
+
 // File represents a file to upload.
 type File struct {
 	Field string
@@ -1,11 +1,17 @@
 package graphql
 
 import (
+	"compress/gzip"
 	"context"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -63,7 +69,41 @@ func TestDoJSONServerError(t *testing.T) {
 	var responseData map[string]interface{}
 	_, err := client.Run(ctx, &Request{q: "query {}"}, &responseData)
 	is.Equal(calls, 1) // calls
-	is.Equal(err.Error(), "graphql: server returned a non-200 status code: 500")
+	is.Equal(err.Error(), `graphql: server returned a non-200 status code: 500 (content type "text/plain; charset=utf-8", body "Internal Server Error")`)
+}
+
+func TestDoJSONHTMLResponse(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<html><body>`+strings.Repeat("Maintenance ", 100)+`</body></html>`)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient(srv.URL)
+	_, err := client.Run(ctx, &Request{q: "query {}"}, nil)
+	is.True(err != nil)
+	is.True(strings.HasPrefix(err.Error(), `decoding response (content type "text/html", body "<html><body>Maintenance Maintenance `))
+	is.True(strings.HasSuffix(err.Error(), `...")`+": invalid character '<' looking for beginning of value"))
+}
+
+func TestMaxErrorBodySize(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusBadGateway)
+		io.WriteString(w, strings.Repeat("x", 1<<20))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient(srv.URL, WithMaxErrorBodySize(10))
+	_, err := client.Run(ctx, &Request{q: "query {}"}, nil)
+	is.Equal(err.Error(), `graphql: server returned a non-200 status code: 502 (content type "text/plain", body "xxxxxxxxxx")`)
+	is.Equal(client.Stats().BytesReceived, int64(10))
 }
 
 func TestDoJSONBadRequestErr(t *testing.T) {
@@ -95,6 +135,66 @@ func TestDoJSONBadRequestErr(t *testing.T) {
 	is.Equal(err.Error(), "graphql: miscellaneous message as to why the the request was bad")
 }
 
+func TestDoJSONGatewayError(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		io.WriteString(w, `{"errors":[{"message":"subgraph unavailable"}]}`)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	_, err := NewClient(srv.URL).Run(ctx, &Request{q: "query {}"}, nil)
+	is.Equal(err.Error(), "graphql: subgraph unavailable")
+
+	client := NewClient(srv.URL, WithErrorBodyStatuses(func(status int) bool { return status < 500 }))
+	_, err = client.Run(ctx, &Request{q: "query {}"}, nil)
+	is.Equal(err.Error(), `graphql: server returned a non-200 status code: 502 (content type "text/plain; charset=utf-8", body "{\"errors\":[{\"message\":\"subgraph unavailable\"}]}")`)
+}
+
+func TestDoJSONErrorStatusWithoutErrors(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, `{}`)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	_, err := NewClient(srv.URL).Run(ctx, &Request{q: "query {}"}, nil)
+	is.Equal(err.Error(), `graphql: server returned a non-200 status code: 503 (content type "application/json", body "{}")`)
+}
+
+func TestDoJSONSuccessStatuses(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("status") {
+		case "202":
+			w.WriteHeader(http.StatusAccepted)
+		case "201":
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"data":{"something":"yes"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	var responseData map[string]interface{}
+	_, err := NewClient(srv.URL+"?status=201").Run(ctx, &Request{q: "mutation {}"}, &responseData)
+	is.NoErr(err)
+	is.Equal(responseData["something"], "yes")
+	_, err = NewClient(srv.URL+"?status=202").Run(ctx, &Request{q: "mutation {}"}, nil)
+	is.NoErr(err)
+
+	client := NewClient(srv.URL+"?status=202", WithSuccessStatuses(func(status int) bool { return status == http.StatusOK }))
+	_, err = client.Run(ctx, &Request{q: "mutation {}"}, nil)
+	is.Equal(err.Error(), `graphql: server returned a non-200 status code: 202 (content type "", body "")`)
+}
+
 func TestQueryJSON(t *testing.T) {
 	is := is.New(t)
 
@@ -159,3 +259,322 @@ func TestHeader(t *testing.T) {
 
 	is.Equal(resp.Value, "some data")
 }
+
+func TestWith(t *testing.T) {
+	is := is.New(t)
+
+	var headers []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header)
+		_, err := io.WriteString(w, `{"data":{"value":"some data"}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithHeader("X-Service", "test"))
+	tenant := client.With(WithHeader("X-Tenant", "acme"), WithTimeout(time.Second))
+	is.Equal(tenant.httpClient, client.httpClient)
+
+	_, err := tenant.Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	_, err = client.Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+
+	is.Equal(headers[0].Get("X-Service"), "test")
+	is.Equal(headers[0].Get("X-Tenant"), "acme")
+	is.Equal(headers[1].Get("X-Service"), "test")
+	is.Equal(headers[1].Get("X-Tenant"), "")
+}
+
+func TestConcurrentRequestReuse(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, strings.NewReader(`{"data":{}}`))
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL)
+	req := NewRequest("query {}")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req.Var("i", i)
+			_, err := client.Run(ctx, req, nil)
+			is.NoErr(err)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestConcurrentRequestAccessors(t *testing.T) {
+	is := is.New(t)
+
+	req := NewRequest("mutation ($i: Int) {}")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req.Var("i", i)
+			req.File("file", "a.txt", strings.NewReader(""))
+			// the copies returned can be read while other goroutines
+			// change the request
+			for range req.Vars() {
+			}
+			for range req.Files() {
+			}
+		}(i)
+	}
+	wg.Wait()
+	is.Equal(len(req.Files()), 10)
+	vars := req.Vars()
+	vars["other"] = true
+	is.Equal(len(req.Vars()), 1)
+}
+
+func TestCloneWithVars(t *testing.T) {
+	is := is.New(t)
+
+	template := NewRequest("query ($id: ID!, $first: Int) {}")
+	template.Var("first", 10)
+	template.Header.Set("X-Custom-Header", "123")
+
+	req := template.CloneWithVars(map[string]interface{}{"id": "abc"})
+	req.Header.Set("X-Other", "456")
+
+	is.Equal(req.Query(), template.Query())
+	is.Equal(req.Vars(), map[string]interface{}{"id": "abc", "first": 10})
+	is.Equal(template.Vars(), map[string]interface{}{"first": 10})
+	is.Equal(req.Header.Get("X-Custom-Header"), "123")
+	is.Equal(template.Header.Get("X-Other"), "")
+}
+
+func TestWithResponseHeaders(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc")
+		w.Header().Set("Set-Cookie", "secret")
+		_, err := io.WriteString(w, `{"data":{}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	res, err := NewClient(srv.URL, WithResponseHeaders("x-request-id")).Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(res.Header, http.Header{"X-Request-Id": {"abc"}})
+
+	res, err = NewClient(srv.URL, WithoutResponseHeaders()).Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(len(res.Header), 0)
+
+	res, err = NewClient(srv.URL).Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(res.Header.Get("Set-Cookie"), "secret")
+}
+
+type tenantKey struct{}
+
+func TestWithHeaderFromContext(t *testing.T) {
+	is := is.New(t)
+
+	var tenants [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenants = append(tenants, r.Header.Values("X-Tenant-Id"))
+		_, err := io.WriteString(w, `{"data":{}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithHeaderFromContext("X-Tenant-Id", tenantKey{}))
+	_, err := client.Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	tenantCtx := context.WithValue(ctx, tenantKey{}, "acme")
+	_, err = client.Run(tenantCtx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	_, err = client.Run(tenantCtx, NewRequest("query {}").WithHeader("X-Tenant-Id", "other"), nil)
+	is.NoErr(err)
+
+	is.Equal(tenants, [][]string{nil, {"acme"}, {"other"}})
+}
+
+type headerKey string
+
+func TestWithHeaderFromContextSiblings(t *testing.T) {
+	is := is.New(t)
+
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		_, err := io.WriteString(w, `{"data":{}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for _, name := range []string{"X-A", "X-B", "X-C", "X-D", "X-E"} {
+		ctx = context.WithValue(ctx, headerKey(name), name)
+	}
+
+	// three headers leave room in the slice for a fourth, which the
+	// siblings mustn't share
+	base := NewClient(srv.URL,
+		WithHeaderFromContext("X-A", headerKey("X-A")),
+		WithHeaderFromContext("X-B", headerKey("X-B")),
+		WithHeaderFromContext("X-C", headerKey("X-C")),
+	)
+	d := base.With(WithHeaderFromContext("X-D", headerKey("X-D")))
+	e := base.With(WithHeaderFromContext("X-E", headerKey("X-E")))
+
+	_, err := d.Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(got.Get("X-D"), "X-D")
+	is.Equal(got.Get("X-E"), "")
+	_, err = e.Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(got.Get("X-D"), "")
+	is.Equal(got.Get("X-E"), "X-E")
+	_, err = base.Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(got.Get("X-C"), "X-C")
+	is.Equal(got.Get("X-D"), "")
+}
+
+func TestUserAgentAndClientAwareness(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("User-Agent"), "billing-service/1.2.3")
+		is.Equal(r.Header.Get("apollographql-client-name"), "billing-service")
+		is.Equal(r.Header.Get("apollographql-client-version"), "1.2.3")
+		_, err := io.WriteString(w, `{"data":{}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL,
+		WithUserAgent("billing-service/1.2.3"),
+		WithClientAwareness("billing-service", "1.2.3"),
+	)
+	_, err := client.Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+}
+
+func TestWithAcceptEncoding(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("Accept-Encoding"), "gzip")
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		io.WriteString(zw, `{"data":{"value":"some data"}}`)
+		is.NoErr(zw.Close())
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithAcceptEncoding("gzip"))
+	var resp struct {
+		Value string
+	}
+	res, err := client.Run(ctx, NewRequest("query {}"), &resp)
+	is.NoErr(err)
+	is.Equal(resp.Value, "some data")
+	is.Equal(res.Header.Get("Content-Encoding"), "gzip")
+	is.True(!res.Uncompressed)
+}
+
+func TestNewClientE(t *testing.T) {
+	is := is.New(t)
+
+	client, err := NewClientE("https://example.com/graphql", WithMaxIdleConnsPerHost(8))
+	is.NoErr(err)
+	is.True(client != nil)
+
+	for _, endpoint := range []string{"", "example.com/graphql", "ftp://example.com", "http://", "http://[::1"} {
+		_, err := NewClientE(endpoint)
+		is.True(err != nil) // invalid endpoint
+	}
+
+	_, err = NewClientE("https://example.com/graphql", WithHTTPClient(&http.Client{}), WithMaxIdleConnsPerHost(8))
+	is.True(strings.Contains(err.Error(), "WithHTTPClient"))
+
+	_, err = NewClientE("https://example.com/graphql", WithRunAllConcurrency(-1))
+	is.True(err != nil)
+}
+
+func TestRequestWithTimeout(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithTimeout(10*time.Millisecond))
+	_, err := client.Run(context.Background(), NewRequest("{ report }"), nil)
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	_, err = client.Run(context.Background(), NewRequest("{ report }").WithTimeout(time.Second), nil)
+	is.NoErr(err)
+}
+
+func TestWithTimeoutCoversRetries(t *testing.T) {
+	is := is.New(t)
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithTimeout(50*time.Millisecond), WithRetry(5, func(int) time.Duration { return time.Millisecond }))
+	_, err := client.Run(context.Background(), NewRequest("{ report }"), nil)
+	is.True(errors.Is(err, ErrDeadlineWouldBeExceeded)) // no time left for a second attempt
+	is.Equal(atomic.LoadInt32(&calls), int32(1))
+}
+
+func TestWithTimeoutHeader(t *testing.T) {
+	is := is.New(t)
+
+	var hints []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hints = append(hints, r.Header.Get("X-Request-Timeout-Ms"))
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithTimeoutHeader("X-Request-Timeout-Ms"))
+	_, err := client.Run(ctx, NewRequest("{ a }"), nil)
+	is.NoErr(err)
+	_, err = client.Run(ctx, NewRequest("{ a }").WithTimeout(time.Second), nil)
+	is.NoErr(err)
+	_, err = client.Run(context.Background(), NewRequest("{ a }"), nil)
+	is.NoErr(err)
+	is.Equal(len(hints), 3)
+	first, err := strconv.Atoi(hints[0])
+	is.NoErr(err)
+	is.True(first > 4000 && first <= 5000)
+	second, err := strconv.Atoi(hints[1])
+	is.NoErr(err)
+	is.True(second > 0 && second <= 1000) // the shorter of the two deadlines
+	is.Equal(hints[2], "")                // no deadline
+}
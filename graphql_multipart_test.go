@@ -137,7 +137,7 @@ func TestDoServerErr(t *testing.T) {
 	defer cancel()
 	var responseData map[string]interface{}
 	_, err := client.Run(ctx, &Request{q: "query {}"}, &responseData)
-	is.Equal(err.Error(), "graphql: server returned a non-200 status code: 500")
+	is.Equal(err.Error(), `graphql: server returned a non-200 status code: 500 (content type "text/plain; charset=utf-8", body "Internal Server Error")`)
 }
 
 func TestDoBadRequestErr(t *testing.T) {
@@ -263,3 +263,101 @@ type roundTripperFunc func(req *http.Request) (*http.Response, error)
 func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return fn(req)
 }
+
+func TestRequestBuilder(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("X-Custom-Header"), "123")
+		is.Equal(r.FormValue("variables"), `{"id":1}`+"\n")
+		file, _, err := r.FormFile("file")
+		is.NoErr(err)
+		defer file.Close()
+		b, err := ioutil.ReadAll(file)
+		is.NoErr(err)
+		is.Equal(string(b), `This is a file`)
+		_, err = io.WriteString(w, `{"data":{}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, UseMultipartForm())
+	_, err := client.Run(ctx, NewRequest("mutation {}").
+		WithVar("id", 1).
+		WithHeader("X-Custom-Header", "123").
+		WithFiles(File{Field: "file", Name: "filename.txt", R: strings.NewReader(`This is a file`)}), nil)
+	is.NoErr(err)
+}
+
+func TestMultipartOrder(t *testing.T) {
+	for _, tt := range []struct {
+		order MultipartOrder
+		parts []string
+	}{
+		{MultipartFieldsFirst, []string{"query", "variables", "b", "a", "c"}},
+		{MultipartFilesFirst, []string{"b", "a", "c", "query", "variables"}},
+	} {
+		is := is.New(t)
+		var parts []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mr, err := r.MultipartReader()
+			is.NoErr(err)
+			for {
+				part, err := mr.NextPart()
+				if err == io.EOF {
+					break
+				}
+				is.NoErr(err)
+				parts = append(parts, part.FormName())
+			}
+			io.WriteString(w, `{"data":{}}`)
+		}))
+		client := NewClient(srv.URL, WithMultipartOrder(tt.order))
+		req := NewRequest("mutation ($n: Int) { upload(n: $n) }")
+		req.Var("n", 1)
+		for _, field := range []string{"b", "a", "c"} {
+			req.File(field, field+".txt", strings.NewReader(field))
+		}
+		_, err := client.Run(context.Background(), req, nil)
+		is.NoErr(err)
+		is.Equal(parts, tt.parts)
+		srv.Close()
+	}
+}
+
+func TestFormField(t *testing.T) {
+	is := is.New(t)
+
+	var parts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mr, err := r.MultipartReader()
+		is.NoErr(err)
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			is.NoErr(err)
+			value, err := io.ReadAll(part)
+			is.NoErr(err)
+			parts = append(parts, part.FormName()+"="+string(value))
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := NewClient(srv.URL)
+	req := NewRequest("query { item }")
+	req.FormField("access_token", "secret")
+	req.FormField("client", "legacy")
+	_, err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(parts, []string{"query=query { item }", "access_token=secret", "client=legacy"})
+
+	req.Transport(TransportJSON)
+	_, err = client.Run(ctx, req, nil)
+	is.Equal(err.Error(), "graphql: cannot send form fields with the JSON transport")
+}
@@ -0,0 +1,142 @@
+package graphql
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WithHandler makes the client send its requests straight to h, in the
+// same process, without opening any sockets, so tests can run a client
+// against a real server, such as one built with gqlgen, quickly and
+// without ports to clash. The endpoint passed to NewClient still gives
+// the URL h sees; any absolute URL will do:
+//
+//	client := graphql.NewClient("http://api.test/query", graphql.WithHandler(srv))
+//
+// Each request is served on a goroutine of its own, as by net/http, and
+// the response streams back as the handler writes it. h sees the
+// context of the request, so canceling it reaches the handler. A handler
+// that panics fails the request, rather than the process.
+//
+// WithHandler replaces the http.Client, as WithHTTPClient does, so the
+// options configuring connections have no effect.
+func WithHandler(h http.Handler) ClientOption {
+	return func(client *Client) {
+		client.httpClient = &http.Client{Transport: handlerTransport{h: h}}
+	}
+}
+
+// handlerTransport is an http.RoundTripper that serves requests with a
+// handler.
+type handlerTransport struct {
+	h http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	pr, pw := io.Pipe()
+	w := &handlerResponseWriter{header: make(http.Header), body: pw, ready: make(chan struct{})}
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				err := fmt.Errorf("graphql: handler panicked: %v", p)
+				if !w.wroteHeader {
+					w.err = err
+					close(w.ready)
+				}
+				pw.CloseWithError(err)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			pw.Close()
+		}()
+		if req.Body != nil {
+			defer req.Body.Close()
+		}
+		t.h.ServeHTTP(w, serverRequest(req))
+	}()
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		pr.CloseWithError(ctx.Err())
+		return nil, ctx.Err()
+	}
+	if w.err != nil {
+		pr.Close()
+		return nil, w.err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", w.status, http.StatusText(w.status)),
+		StatusCode:    w.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        w.sent,
+		Body:          pr,
+		ContentLength: -1,
+		Request:       req,
+	}, nil
+}
+
+// serverRequest makes req look as it would to a handler run by an
+// http.Server.
+func serverRequest(req *http.Request) *http.Request {
+	r := req.Clone(req.Context())
+	if r.Body == nil {
+		r.Body = http.NoBody
+	}
+	if r.Host == "" {
+		r.Host = req.URL.Host
+	}
+	r.RequestURI = req.URL.RequestURI()
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/1.1", 1, 1
+	return r
+}
+
+// handlerResponseWriter is the http.ResponseWriter a handlerTransport
+// serves each request with. The headers are sent, by closing ready,
+// once the handler writes them; the body goes down the pipe.
+type handlerResponseWriter struct {
+	header      http.Header
+	sent        http.Header
+	status      int
+	wroteHeader bool
+	body        *io.PipeWriter
+	ready       chan struct{}
+	// err is why the handler failed before sending the headers.
+	err error
+}
+
+func (w *handlerResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *handlerResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	w.sent = w.header.Clone()
+	close(w.ready)
+}
+
+func (w *handlerResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.header.Get("Content-Type") == "" && len(p) > 0 {
+			w.header.Set("Content-Type", http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.body.Write(p)
+}
+
+// Flush does nothing, as writes are unbuffered, but lets handlers that
+// stream their responses find an http.Flusher.
+func (w *handlerResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+}
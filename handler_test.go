@@ -0,0 +1,95 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWithHandler(t *testing.T) {
+	is := is.New(t)
+
+	var methods []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		is.Equal(r.Host, "api.test")
+		is.Equal(r.URL.Path, "/query")
+		is.Equal(r.Header.Get("X-Test"), "yes")
+		var body struct {
+			Query     string
+			Variables map[string]interface{}
+		}
+		if r.Method == http.MethodGet {
+			body.Query = r.URL.Query().Get("query")
+		} else {
+			is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		}
+		is.Equal(body.Query, "query { item }")
+		w.Header().Set("X-Served", "in process")
+		io.WriteString(w, `{"data":{"item":"some data"}}`)
+	})
+	client := NewClient("http://api.test/query", WithHandler(handler), WithHeader("X-Test", "yes"))
+	for _, transport := range []Transport{TransportJSON, TransportGET} {
+		req := NewRequest("query { item }")
+		req.Transport(transport)
+		var resp struct {
+			Item string
+		}
+		res, err := client.Run(context.Background(), req, &resp)
+		is.NoErr(err)
+		is.Equal(resp.Item, "some data")
+		is.Equal(res.Header.Get("X-Served"), "in process")
+		is.Equal(res.Header.Get("Content-Type"), "text/plain; charset=utf-8")
+	}
+	is.Equal(methods, []string{http.MethodPost, http.MethodGet})
+}
+
+func TestWithHandlerStatus(t *testing.T) {
+	is := is.New(t)
+
+	client := NewClient("http://api.test/query", WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	})))
+	_, err := client.Run(context.Background(), NewRequest("query { item }"), nil)
+	var httpErr *HTTPError
+	is.True(errors.As(err, &httpErr))
+	is.Equal(httpErr.StatusCode, http.StatusForbidden)
+	is.Equal(strings.TrimSpace(string(httpErr.Body)), "nope")
+}
+
+func TestWithHandlerPanic(t *testing.T) {
+	is := is.New(t)
+
+	client := NewClient("http://api.test/query", WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("oops")
+	})))
+	_, err := client.Run(context.Background(), NewRequest("query { item }"), nil)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "handler panicked: oops"))
+}
+
+func TestWithHandlerCancel(t *testing.T) {
+	is := is.New(t)
+
+	started := make(chan struct{})
+	done := make(chan error, 1)
+	client := NewClient("http://api.test/query", WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		done <- r.Context().Err()
+	})))
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := client.Run(ctx, NewRequest("query { item }"), nil)
+	is.True(errors.Is(err, context.Canceled))
+	is.Equal(<-done, context.Canceled)
+}
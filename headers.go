@@ -0,0 +1,40 @@
+package graphql
+
+import "net/http"
+
+// WithResponseHeaders limits the response headers kept on the
+// Response returned by Run to those named. By default every header
+// is kept; keeping only what's needed saves copying whole header maps
+// around and avoids sensitive headers ending up in logs.
+func WithResponseHeaders(names ...string) ClientOption {
+	return func(client *Client) {
+		client.keepHeaders = make([]string, len(names))
+		for i, name := range names {
+			client.keepHeaders[i] = http.CanonicalHeaderKey(name)
+		}
+	}
+}
+
+// WithoutResponseHeaders drops all headers from the Response returned
+// by Run.
+func WithoutResponseHeaders() ClientOption {
+	return WithResponseHeaders()
+}
+
+// captureHeaders applies the client's response header policy to res.
+func (c *Client) captureHeaders(res *http.Response) {
+	if res == nil || c.keepHeaders == nil {
+		return
+	}
+	if len(c.keepHeaders) == 0 {
+		res.Header = nil
+		return
+	}
+	kept := make(http.Header, len(c.keepHeaders))
+	for _, name := range c.keepHeaders {
+		if values, ok := res.Header[name]; ok {
+			kept[name] = values
+		}
+	}
+	res.Header = kept
+}
@@ -0,0 +1,67 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ID is a value of the GraphQL ID scalar. Servers may return IDs as
+// strings or as numbers; ID decodes either, keeping a number exactly as
+// it was written rather than converting it to a float64, and always
+// encodes as a string, which every server accepts as an ID.
+//
+//	var resp struct {
+//	    Item struct {
+//	        ID graphql.ID
+//	    }
+//	}
+type ID string
+
+// IntID returns the ID of the number n.
+func IntID(n int64) ID {
+	return ID(strconv.FormatInt(n, 10))
+}
+
+// String returns the ID as a string.
+func (id ID) String() string {
+	return string(id)
+}
+
+// Int64 returns the ID as a number, for IDs that are numbers.
+func (id ID) Int64() (int64, error) {
+	n, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("graphql: ID %q is not a number", string(id))
+	}
+	return n, nil
+}
+
+// MarshalJSON encodes the ID as a JSON string.
+func (id ID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(id))
+}
+
+// UnmarshalJSON decodes an ID from a JSON string or number. A null
+// leaves the ID unchanged.
+func (id *ID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = ID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("graphql: ID must be a string or a number, not %s", data)
+	}
+	*id = ID(n)
+	return nil
+}
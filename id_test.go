@@ -0,0 +1,35 @@
+package graphql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestID(t *testing.T) {
+	is := is.New(t)
+
+	var resp struct {
+		Items []struct {
+			ID ID
+		}
+	}
+	is.NoErr(json.Unmarshal([]byte(`{"items":[{"id":"abc"},{"id":12345678901234567},{"id":null}]}`), &resp))
+	is.Equal(resp.Items[0].ID, ID("abc"))
+	is.Equal(resp.Items[1].ID, ID("12345678901234567")) // not rounded through a float64
+	is.Equal(resp.Items[2].ID, ID(""))
+	n, err := resp.Items[1].ID.Int64()
+	is.NoErr(err)
+	is.Equal(n, int64(12345678901234567))
+	_, err = resp.Items[0].ID.Int64()
+	is.Equal(err.Error(), `graphql: ID "abc" is not a number`)
+
+	b, err := json.Marshal(map[string]ID{"a": IntID(42), "b": "xyz"})
+	is.NoErr(err)
+	is.Equal(string(b), `{"a":"42","b":"xyz"}`)
+
+	var id ID
+	err = json.Unmarshal([]byte(`true`), &id)
+	is.Equal(err.Error(), "graphql: ID must be a string or a number, not true")
+}
@@ -0,0 +1,71 @@
+package graphql
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// defaultIdempotencyKeyHeader is the header idempotency keys are sent in
+// unless WithIdempotencyKeyHeader says otherwise.
+const defaultIdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyKey sets a key identifying this logical operation, sent to
+// the server in the Idempotency-Key header so that servers supporting it
+// apply a retried mutation only once. Retries made by the client or a
+// Queue reuse the same key.
+func (req *Request) IdempotencyKey(key string) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.idempotencyKey = key
+}
+
+// WithIdempotencyKeyHeader sets the name of the header idempotency keys
+// are sent in.
+func WithIdempotencyKeyHeader(name string) ClientOption {
+	return func(client *Client) {
+		client.idempotencyHeader = name
+	}
+}
+
+// WithAutoIdempotencyKeys generates an idempotency key for each mutation
+// that doesn't already have one. A generated key doesn't make the
+// mutation safe to retry, as the server may not honor it; mark the
+// request with Request.Idempotent for WithRetry and queues to retry it.
+func WithAutoIdempotencyKeys() ClientOption {
+	return func(client *Client) {
+		client.autoIdempotencyKeys = true
+	}
+}
+
+func (c *Client) idempotencyKeyHeader() string {
+	if c.idempotencyHeader != "" {
+		return c.idempotencyHeader
+	}
+	return defaultIdempotencyKeyHeader
+}
+
+// withIdempotencyKey returns req, or a copy of it with a generated
+// idempotency key if the client would generate one for it anyway, so the
+// same key can be used for every attempt.
+func (c *Client) withIdempotencyKey(req *Request) *Request {
+	if !c.autoIdempotencyKeys || operationType(req.Query()) != "mutation" {
+		return req
+	}
+	req = req.Clone()
+	if req.idempotencyKey == "" {
+		req.idempotencyKey = newIdempotencyKey()
+		req.generatedKey = true
+	}
+	return req
+}
+
+// newIdempotencyKey returns a random (version 4) UUID.
+func newIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
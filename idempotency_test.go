@@ -0,0 +1,73 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	is := is.New(t)
+
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("X-Idempotency-Key"))
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithIdempotencyKeyHeader("X-Idempotency-Key"))
+	req := NewRequest("mutation { pay }")
+	req.IdempotencyKey("abc")
+	_, err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	_, err = client.Run(ctx, NewRequest("mutation { pay }"), nil)
+	is.NoErr(err)
+	is.Equal(keys, []string{"abc", ""})
+}
+
+func TestAutoIdempotencyKeysReusedAcrossRetries(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithAutoIdempotencyKeys())
+	q := client.NewQueue(WithQueueRetries(2, func(int) time.Duration { return time.Millisecond }))
+	req := NewRequest("mutation { track }")
+	req.Idempotent()
+	is.NoErr(q.Enqueue(req))
+	eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(keys) == 2
+	})
+	q.Close()
+	is.Equal(len(keys[0]), 36)
+	is.Equal(keys[0], keys[1])
+
+	_, err := client.Run(context.Background(), NewRequest("query { read }"), nil)
+	is.NoErr(err)
+	is.Equal(keys[2], "") // queries don't get keys
+}
@@ -0,0 +1,195 @@
+package graphql
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Input is a GraphQL input object, built up field by field so that a
+// field that is unset, one set to null and one set to its zero value
+// can't be confused. That matters for partial updates, where servers
+// leave unset fields alone but clear those that are null:
+//
+//	input := graphql.Input{}.
+//	    Set("name", "Table").
+//	    Null("description")
+//	req.Var("input", input) // {"description":null,"name":"Table"}
+type Input map[string]interface{}
+
+// Set sets the field to value, which may be an Input, and returns the
+// input.
+func (in Input) Set(field string, value interface{}) Input {
+	in[field] = value
+	return in
+}
+
+// Null sets the field to null and returns the input.
+func (in Input) Null(field string) Input {
+	in[field] = nil
+	return in
+}
+
+// Unset removes the field, so that it isn't sent, and returns the input.
+func (in Input) Unset(field string) Input {
+	delete(in, field)
+	return in
+}
+
+// IsSet reports whether the field is set, to null or to a value.
+func (in Input) IsSet(field string) bool {
+	_, ok := in[field]
+	return ok
+}
+
+// InputFrom makes an Input from the exported fields of the struct v, or
+// a pointer to one. Fields are named by their graphql tag, or else by
+// their Go name with its first letter, or initialism, in lower case:
+//
+//	type ItemInput struct {
+//	    ID          graphql.ID `graphql:"id"`
+//	    Name        string     `graphql:",omitempty"`
+//	    Description *string    `graphql:"description,omitempty"`
+//	    Internal    string     `graphql:"-"`
+//	}
+//
+// A field tagged omitempty is left unset if it has its zero value, so
+// nil pointers are left unset rather than sent as null; without
+// omitempty a nil pointer is sent as null. Nested structs, and slices of
+// them, become Inputs in turn, except for types that encode themselves
+// such as time.Time. The fields of embedded structs are added as if
+// they were fields of v.
+func InputFrom(v interface{}) (Input, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("graphql: InputFrom needs a struct, not %T", v)
+	}
+	in := Input{}
+	addInputFields(in, rv)
+	return in, nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func addInputFields(in Input, rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, hasTag := field.Tag.Lookup("graphql")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		omitEmpty := opts == "omitempty"
+		value := rv.Field(i)
+		if field.Anonymous && !hasTag {
+			embedded := value
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addInputFields(in, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = lowerFirst(field.Name)
+		}
+		if omitEmpty && value.IsZero() {
+			continue
+		}
+		in[name] = inputValue(value)
+	}
+}
+
+// inputValue returns the value to send for v, with structs made into
+// Inputs.
+func inputValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if encodesItself(v.Type()) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return inputValue(v.Elem())
+	case reflect.Struct:
+		in := Input{}
+		addInputFields(in, v)
+		return in
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if !containsStructs(v.Type().Elem()) {
+			return v.Interface()
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = inputValue(v.Index(i))
+		}
+		return values
+	}
+	return v.Interface()
+}
+
+// encodesItself reports whether values of t are encoded by their own
+// methods rather than field by field.
+func encodesItself(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// containsStructs reports whether values of t may need converting to
+// Inputs.
+func containsStructs(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if encodesItself(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Interface:
+		return true
+	case reflect.Slice, reflect.Array:
+		return containsStructs(t.Elem())
+	}
+	return false
+}
+
+// lowerFirst lowers the leading capital, or initialism, of a Go name,
+// so that Name becomes name, ID id and URLPath urlPath.
+func lowerFirst(s string) string {
+	runes := []rune(s)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			// the start of the next word
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,77 @@
+package graphql
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestInput(t *testing.T) {
+	is := is.New(t)
+
+	input := Input{}.Set("name", "Table").Set("price", 0).Null("description").Set("tags", Input{}.Set("a", 1))
+	is.True(input.IsSet("description"))
+	is.True(!input.IsSet("missing"))
+	input.Unset("tags")
+	b, err := json.Marshal(input)
+	is.NoErr(err)
+	is.Equal(string(b), `{"description":null,"name":"Table","price":0}`)
+}
+
+type testAudit struct {
+	UpdatedBy string `graphql:"updatedBy,omitempty"`
+}
+
+type testItemInput struct {
+	testAudit
+	ID          ID `graphql:"id"`
+	Name        string
+	URLPath     string     `graphql:",omitempty"`
+	Description *string    `graphql:"description,omitempty"`
+	Parent      *string    // sent as null when nil
+	Price       int        `graphql:"price"`
+	Internal    string     `graphql:"-"`
+	Status      testStatus `graphql:"status,omitempty"`
+	Options     []testOption
+	At          time.Time `graphql:"at,omitempty"`
+	secret      string
+}
+
+type testOption struct {
+	Key   string `graphql:"key"`
+	Value string `graphql:"value,omitempty"`
+}
+
+func TestInputFrom(t *testing.T) {
+	is := is.New(t)
+
+	in, err := InputFrom(&testItemInput{
+		testAudit: testAudit{UpdatedBy: "ops"},
+		ID:        "1",
+		Name:      "Table",
+		Internal:  "x",
+		Status:    "SOLD",
+		Options:   []testOption{{Key: "color"}},
+		At:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		secret:    "hidden",
+	})
+	is.NoErr(err)
+	b, err := json.Marshal(in)
+	is.NoErr(err)
+	is.Equal(string(b), `{"at":"2024-01-02T03:04:05Z","id":"1","name":"Table","options":[{"key":"color"}],"parent":null,"price":0,"status":"SOLD","updatedBy":"ops"}`)
+
+	is.Equal(lowerFirst("URLPath"), "urlPath")
+	is.Equal(lowerFirst("ID"), "id")
+
+	_, err = InputFrom("nope")
+	is.Equal(err.Error(), "graphql: InputFrom needs a struct, not string")
+
+	// enums within inputs are checked before sending
+	w := variableWalker{}
+	bad, err := InputFrom(testItemInput{Status: "GONE"})
+	is.NoErr(err)
+	_, _, enumErr := w.walkMap(map[string]interface{}{"input": bad})
+	is.Equal(enumErr.Error(), `graphql: variable $input.status: "GONE" is not a value of the enum`)
+}
@@ -0,0 +1,267 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Diagnostic is a problem Lint found in a document.
+type Diagnostic struct {
+	// Line and Column locate the problem, both starting at 1.
+	Line, Column int
+	// Rule names the check that found the problem, such as
+	// unused-variable.
+	Rule string
+	// Message describes the problem.
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", d.Line, d.Column, d.Message, d.Rule)
+}
+
+// LintError is returned by NewLintedRequest for a document Lint finds
+// problems with.
+type LintError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *LintError) Error() string {
+	lines := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		lines[i] = "graphql: lint: " + d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Lint checks the document q for mistakes that need no schema to spot,
+// returning a diagnostic for each, in the order they appear:
+//
+//   - unused-variable: a variable an operation defines but doesn't use
+//   - undefined-variable: a variable an operation, or a fragment it
+//     spreads, uses without the operation defining it
+//   - duplicate-field: a field selected twice, identically, in the same
+//     selection set
+//   - duplicate-alias: a response name used for two different fields,
+//     or the same field with different arguments, in the same selection
+//     set, which servers reject
+//   - anonymous-operation: an operation without a name in a document
+//     with more than one operation
+//
+// It returns an error only if q can't be parsed.
+func Lint(q string) ([]Diagnostic, error) {
+	doc, err := parseDocument(q)
+	if err != nil {
+		return nil, err
+	}
+	l := &linter{doc: doc, fragments: make(map[string]*fragmentDef)}
+	for _, frag := range doc.fragments {
+		l.fragments[frag.name] = frag
+	}
+	for _, op := range doc.operations {
+		if op.name == "" && len(doc.operations) > 1 {
+			l.report(doc.tokens[op.start], "anonymous-operation", "anonymous operation in a document with more than one operation")
+		}
+		l.variables(op)
+		l.selectionSet(op.selStart, op.selEnd)
+	}
+	for _, frag := range doc.fragments {
+		start, err := doc.skipToSelectionSet(frag.start + 2)
+		if err == nil {
+			l.selectionSet(start, frag.end)
+		}
+	}
+	sortDiagnostics(l.diagnostics)
+	return l.diagnostics, nil
+}
+
+// NewLintedRequest makes a new Request as NewRequest does, having first
+// checked q with Lint. It returns a LintError if Lint finds problems,
+// or the parse error if q can't be parsed.
+func NewLintedRequest(q string) (*Request, error) {
+	diagnostics, err := Lint(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(diagnostics) > 0 {
+		return nil, &LintError{Diagnostics: diagnostics}
+	}
+	return NewRequest(q), nil
+}
+
+// linter walks a document collecting diagnostics.
+type linter struct {
+	doc         *document
+	fragments   map[string]*fragmentDef
+	diagnostics []Diagnostic
+}
+
+func (l *linter) report(t token, rule, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Line:    t.line,
+		Column:  t.col,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// variables checks the variables op defines against those it uses,
+// directly or in the fragments it spreads.
+func (l *linter) variables(op *operationDef) {
+	tokens := l.doc.tokens
+	defined := make(map[string]bool)
+	used := make(map[string]bool)
+	var defs [][]token
+	if op.varsEnd > 0 {
+		defs = splitVariableDefinitions(tokens[op.varsStart:op.varsEnd])
+	}
+	for _, def := range defs {
+		if len(def) > 1 {
+			defined[def[1].text] = true
+		}
+	}
+	// uses start after the definitions, whose default values can't
+	// refer to variables
+	start := op.start
+	if op.varsEnd > 0 {
+		start = op.varsEnd
+	}
+	seen := make(map[string]bool)
+	ranges := [][2]int{{start, op.end}}
+	for len(ranges) > 0 {
+		r := ranges[0]
+		ranges = ranges[1:]
+		for i := r[0]; i < r[1]-1; i++ {
+			switch t := tokens[i]; {
+			case t.is("$") && tokens[i+1].kind == tokenName:
+				name := tokens[i+1].text
+				used[name] = true
+				if !defined[name] {
+					l.report(t, "undefined-variable", "variable $%s is not defined by operation %s", name, operationLabel(op))
+				}
+			case t.is("...") && tokens[i+1].kind == tokenName && !tokens[i+1].isName("on"):
+				name := tokens[i+1].text
+				if frag := l.fragments[name]; frag != nil && !seen[name] {
+					seen[name] = true
+					ranges = append(ranges, [2]int{frag.start, frag.end})
+				}
+			}
+		}
+	}
+	for _, def := range defs {
+		if len(def) > 1 && !used[def[1].text] {
+			l.report(def[0], "unused-variable", "variable $%s is never used", def[1].text)
+		}
+	}
+}
+
+// operationLabel names op in diagnostics.
+func operationLabel(op *operationDef) string {
+	if op.name == "" {
+		return "(anonymous " + op.typ + ")"
+	}
+	return op.name
+}
+
+// selectionSet checks the selection set spanning tokens[start:end], and
+// those nested within it, for duplicate fields.
+func (l *linter) selectionSet(start, end int) {
+	tokens := l.doc.tokens
+	last := end - 1
+	type selected struct {
+		// field is the field's name and arguments, and text the whole
+		// selection.
+		field, text string
+	}
+	keys := make(map[string]selected)
+	for i := start + 1; i < last; {
+		t := tokens[i]
+		if t.is("...") {
+			i++
+			if i < last && tokens[i].isName("on") {
+				i += 2
+			} else if i < last && tokens[i].kind == tokenName {
+				i = l.skipDirectives(i+1, last)
+				continue
+			}
+			i = l.skipDirectives(i, last)
+			if i >= last || !tokens[i].is("{") {
+				return
+			}
+			next, err := l.doc.skipBalanced(i)
+			if err != nil {
+				return
+			}
+			l.selectionSet(i, next)
+			i = next
+			continue
+		}
+		if t.kind != tokenName {
+			return
+		}
+		fieldStart, nameStart := i, i
+		i++
+		if i < last && tokens[i].is(":") {
+			nameStart = i + 1
+			i += 2
+		}
+		if i < last && tokens[i].is("(") {
+			next, err := l.doc.skipBalanced(i)
+			if err != nil {
+				return
+			}
+			i = next
+		}
+		field := joinTokens(tokens[nameStart:i])
+		i = l.skipDirectives(i, last)
+		if i < last && tokens[i].is("{") {
+			next, err := l.doc.skipBalanced(i)
+			if err != nil {
+				return
+			}
+			l.selectionSet(i, next)
+			i = next
+		}
+		key := t.text
+		sel := selected{field: field, text: joinTokens(tokens[fieldStart:i])}
+		prev, ok := keys[key]
+		switch {
+		case !ok:
+			keys[key] = sel
+		case prev.text == sel.text:
+			l.report(t, "duplicate-field", "field %q is selected more than once", key)
+		case prev.field != sel.field:
+			l.report(t, "duplicate-alias", "response name %q is used for different fields", key)
+		}
+	}
+}
+
+// skipDirectives returns the index of the first token at or after
+// tokens[i] that isn't part of a directive.
+func (l *linter) skipDirectives(i, last int) int {
+	tokens := l.doc.tokens
+	for i+1 < last && tokens[i].is("@") {
+		i += 2
+		if i < last && tokens[i].is("(") {
+			next, err := l.doc.skipBalanced(i)
+			if err != nil {
+				return last
+			}
+			i = next
+		}
+	}
+	return i
+}
+
+// sortDiagnostics sorts diagnostics by position, keeping those at the
+// same position in the order they were found.
+func sortDiagnostics(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
@@ -0,0 +1,73 @@
+package graphql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLint(t *testing.T) {
+	is := is.New(t)
+
+	diagnostics, err := Lint(`
+query Items($first: Int, $unused: String) {
+  items(first: $first) { ...ItemFields }
+  items(first: $first) { id }
+}
+
+query ($id: ID!) {
+  item(id: $id) {
+    name
+    name
+    name: description
+  }
+}
+
+fragment ItemFields on Item {
+  id
+  tags(after: $after)
+}
+`)
+	is.NoErr(err)
+	is.Equal(diagnostics, []Diagnostic{
+		{Line: 2, Column: 26, Rule: "unused-variable", Message: "variable $unused is never used"},
+		{Line: 7, Column: 1, Rule: "anonymous-operation", Message: "anonymous operation in a document with more than one operation"},
+		{Line: 10, Column: 5, Rule: "duplicate-field", Message: `field "name" is selected more than once`},
+		{Line: 11, Column: 5, Rule: "duplicate-alias", Message: `response name "name" is used for different fields`},
+		{Line: 17, Column: 15, Rule: "undefined-variable", Message: "variable $after is not defined by operation Items"},
+	})
+	is.Equal(diagnostics[0].String(), "2:26: variable $unused is never used (unused-variable)")
+}
+
+func TestLintClean(t *testing.T) {
+	is := is.New(t)
+
+	diagnostics, err := Lint(`
+query Item($id: ID!, $withTags: Boolean = false) {
+  item(id: $id) {
+    id
+    ... on Item @include(if: $withTags) { tags }
+    a: tags
+    tags
+  }
+}`)
+	is.NoErr(err)
+	is.Equal(len(diagnostics), 0)
+
+	_, err = Lint("query {")
+	is.True(err != nil)
+}
+
+func TestNewLintedRequest(t *testing.T) {
+	is := is.New(t)
+
+	req, err := NewLintedRequest("query ($id: ID) { item(id: $id) { id } }")
+	is.NoErr(err)
+	is.Equal(req.Query(), "query ($id: ID) { item(id: $id) { id } }")
+
+	_, err = NewLintedRequest("query ($id: ID) { items { id } }")
+	var lintErr *LintError
+	is.True(errors.As(err, &lintErr))
+	is.Equal(err.Error(), "graphql: lint: 1:8: variable $id is never used (unused-variable)")
+}
@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// Live runs a live query, sending a Result on the returned channel each
+// time the result changes until ctx is done, when the channel is closed.
+//
+// The client has no streaming transport for servers to push @live
+// results over, so Live falls back to polling: the query is run every
+// interval with Poll, with any @live directive removed for servers that
+// don't understand it, and a Result is only sent when the data or error
+// differs from the last one sent.
+//
+// As with Poll, an interval that isn't positive is refused with an
+// error.
+func (c *Client) Live(ctx context.Context, req *Request, interval time.Duration) <-chan Result {
+	poll := req.Clone()
+	poll.q = withoutLiveDirective(poll.q)
+	results := make(chan Result)
+	go func() {
+		defer close(results)
+		var last *Result
+		for result := range c.Poll(ctx, poll, interval, WithPollJitter(0)) {
+			if last != nil && sameResult(*last, result) {
+				continue
+			}
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+			last = &result
+		}
+	}()
+	return results
+}
+
+// sameResult reports whether two results of a live query hold the same
+// data or error.
+func sameResult(a, b Result) bool {
+	if (a.Err == nil) != (b.Err == nil) {
+		return false
+	}
+	if a.Err != nil {
+		return a.Err.Error() == b.Err.Error()
+	}
+	return bytes.Equal(a.Data, b.Data)
+}
+
+// withoutLiveDirective removes @live from the operations in the
+// document q. Documents that can't be parsed are returned as they are.
+func withoutLiveDirective(q string) string {
+	doc, err := parseDocument(q)
+	if err != nil {
+		return q
+	}
+	drop := make(map[int]bool)
+	for _, op := range doc.operations {
+		for i := op.start; i+1 < op.selStart; i++ {
+			if !doc.tokens[i].is("@") || !doc.tokens[i+1].isName("live") {
+				continue
+			}
+			end := i + 2
+			if end < op.selStart && doc.tokens[end].is("(") {
+				if end, err = doc.skipBalanced(end); err != nil {
+					return q
+				}
+			}
+			for j := i; j < end; j++ {
+				drop[j] = true
+			}
+		}
+	}
+	if len(drop) == 0 {
+		return q
+	}
+	tokens := make([]token, 0, len(doc.tokens)-len(drop))
+	for i, t := range doc.tokens {
+		if !drop[i] {
+			tokens = append(tokens, t)
+		}
+	}
+	return joinTokens(tokens)
+}
@@ -0,0 +1,58 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestLive(t *testing.T) {
+	is := is.New(t)
+
+	// the value changes on the third and fifth polls
+	values := []string{"a", "a", "b", "b", "c"}
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		is.Equal(body.Query, "query Items { items }")
+		n := int(calls.Add(1)) - 1
+		if n >= len(values) {
+			n = len(values) - 1
+		}
+		fmt.Fprintf(w, `{"data":{"items":%q}}`, values[n])
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL)
+	results := client.Live(ctx, NewRequest("query Items @live { items }"), time.Millisecond)
+	for _, want := range []string{`{"items":"a"}`, `{"items":"b"}`, `{"items":"c"}`} {
+		result := <-results
+		is.NoErr(result.Err)
+		is.Equal(string(result.Data), want)
+	}
+	is.True(calls.Load() >= 5)
+	cancel()
+	for range results {
+		// drain until closed
+	}
+}
+
+func TestWithoutLiveDirective(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(withoutLiveDirective("query Items @live { items }"), "query Items { items }")
+	is.Equal(withoutLiveDirective("query @live(throttle: 100) @cached { items @live }"), "query @cached { items @live }")
+	is.Equal(withoutLiveDirective("{ items }"), "{ items }")
+}
@@ -0,0 +1,168 @@
+package graphql
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoadTestReport is the outcome of Client.LoadTest. The embedded Stats
+// count the requests made by the load test alone, and its latency
+// percentiles are over every request rather than just the most recent.
+type LoadTestReport struct {
+	Stats
+	// Duration is how long the load test ran for.
+	Duration time.Duration
+	// Rate is the number of requests completed per second.
+	Rate float64
+	// ErrorRate is the fraction of requests that failed.
+	ErrorRate float64
+	// LatencyMax is the latency of the slowest request.
+	LatencyMax time.Duration
+	// Missed counts the requests that weren't sent on time because every
+	// worker was busy, which means the target rate is more than the
+	// concurrency allows.
+	Missed int64
+}
+
+// LoadTest runs req over and over to measure how the server copes with
+// load, and reports latency percentiles and error rates.
+// By default it runs 10 requests at a time, as fast as they complete,
+// for 10 seconds; see WithLoadTestRate, WithLoadTestConcurrency and
+// WithLoadTestDuration.
+//
+// The requests are made with a client derived from c, as With does, so
+// they use its transport and options without counting towards its Stats.
+// LoadTest stops early if ctx is done.
+func (c *Client) LoadTest(ctx context.Context, req *Request, opts ...LoadTestOption) *LoadTestReport {
+	lt := &loadTest{
+		concurrency: 10,
+		duration:    10 * time.Second,
+	}
+	for _, optionFunc := range opts {
+		optionFunc(lt)
+	}
+	if lt.concurrency <= 0 {
+		lt.concurrency = 1
+	}
+	client := c.With()
+	// requests still running at the end are left to finish, so they
+	// aren't counted as failures
+	runCtx := ctx
+	ctx, cancel := context.WithTimeout(ctx, lt.duration)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		missed    atomic.Int64
+		wg        sync.WaitGroup
+	)
+	// each value sent on ticks is a request for a worker to make
+	ticks := make(chan struct{})
+	start := time.Now()
+	for i := 0; i < lt.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range ticks {
+				begin := time.Now()
+				client.Run(runCtx, req, nil)
+				latency := time.Since(begin)
+				mu.Lock()
+				latencies = append(latencies, latency)
+				mu.Unlock()
+			}
+		}()
+	}
+	if lt.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / lt.rate))
+	paced:
+		for {
+			select {
+			case <-ctx.Done():
+				break paced
+			case <-ticker.C:
+				select {
+				case ticks <- struct{}{}:
+				default:
+					missed.Add(1)
+				}
+			}
+		}
+		ticker.Stop()
+	} else {
+	unpaced:
+		for {
+			select {
+			case <-ctx.Done():
+				break unpaced
+			case ticks <- struct{}{}:
+			}
+		}
+	}
+	close(ticks)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := &LoadTestReport{
+		Stats:    client.Stats(),
+		Duration: elapsed,
+		Missed:   missed.Load(),
+	}
+	n := len(latencies)
+	if n == 0 {
+		return report
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p int) time.Duration {
+		return latencies[(n-1)*p/100]
+	}
+	report.LatencyP50 = percentile(50)
+	report.LatencyP90 = percentile(90)
+	report.LatencyP99 = percentile(99)
+	report.LatencyMax = latencies[n-1]
+	report.Rate = float64(n) / elapsed.Seconds()
+	failures := report.TransportFailures + report.HTTPFailures + report.GraphQLFailures
+	if report.Requests > 0 {
+		report.ErrorRate = float64(failures) / float64(report.Requests)
+	}
+	return report
+}
+
+// loadTest holds the settings of a load test.
+type loadTest struct {
+	rate        float64
+	concurrency int
+	duration    time.Duration
+}
+
+// LoadTestOption are functions that are passed into LoadTest to
+// modify how the load is applied.
+type LoadTestOption func(*loadTest)
+
+// WithLoadTestRate sets the number of requests per second to start.
+// The default of zero starts a request as soon as a worker is free.
+func WithLoadTestRate(rps float64) LoadTestOption {
+	return func(lt *loadTest) {
+		lt.rate = rps
+	}
+}
+
+// WithLoadTestConcurrency sets the number of requests that may run at
+// once. The default is 10.
+func WithLoadTestConcurrency(n int) LoadTestOption {
+	return func(lt *loadTest) {
+		lt.concurrency = n
+	}
+}
+
+// WithLoadTestDuration sets how long the load test runs for. The
+// default is 10 seconds.
+func WithLoadTestDuration(d time.Duration) LoadTestOption {
+	return func(lt *loadTest) {
+		lt.duration = d
+	}
+}
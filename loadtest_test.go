@@ -0,0 +1,44 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestLoadTest(t *testing.T) {
+	is := is.New(t)
+
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1)%4 == 0 {
+			io.WriteString(w, `{"errors":[{"message":"Something went wrong"}]}`)
+			return
+		}
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	report := client.LoadTest(context.Background(), NewRequest("query {}"),
+		WithLoadTestRate(200),
+		WithLoadTestConcurrency(4),
+		WithLoadTestDuration(200*time.Millisecond),
+	)
+	is.True(report.Requests > 10)
+	is.True(report.Requests <= 41)
+	is.Equal(report.Requests, calls.Load())
+	is.Equal(report.GraphQLFailures, report.Requests/4)
+	is.Equal(report.ErrorRate, float64(report.Requests/4)/float64(report.Requests))
+	is.True(report.LatencyP50 > 0)
+	is.True(report.LatencyP50 <= report.LatencyP99)
+	is.True(report.LatencyP99 <= report.LatencyMax)
+	is.True(report.Rate > 0)
+	is.Equal(client.Stats().Requests, int64(0)) // load test requests aren't counted
+}
@@ -0,0 +1,233 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RunMerged executes several independent requests as a single GraphQL
+// request.
+//
+// The root fields and variables of each request are aliased with a
+// prefix unique to that request so the documents can't collide, and the
+// response is split back up again: the data for reqs[i] is unmarshalled
+// into resps[i], in the same way Run would have done.
+// A nil entry in resps skips parsing for that request.
+//
+// The returned slice holds a ResponseError with the GraphQL errors for
+// each request, or nil if that request succeeded. Errors that can't be
+// attributed to a single request (those without a path) are reported
+// for every request. The error return is non-nil only when the merged
+// request could not be built or executed at all.
+//
+// The merged request is retried, and reported to the EventListener, as
+// Run would do for a single request.
+//
+// Every request must contain a single operation, all of the same type,
+// without root level fragment spreads or files. Headers are combined,
+// with the first request to set a header winning.
+func (c *Client) RunMerged(ctx context.Context, reqs []*Request, resps []interface{}) ([]error, error) {
+	if len(resps) != len(reqs) {
+		return nil, errors.New("graphql: merge: need one response object per request")
+	}
+	if !c.state.begin() {
+		return nil, ErrClientClosed
+	}
+	defer c.state.end()
+	for _, req := range reqs {
+		if err := c.checkAllowed(req.Query()); err != nil {
+			return nil, err
+		}
+	}
+	merged, err := mergeRequests(reqs)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]json.RawMessage
+	gr := &graphResponse{
+		Data: &data,
+	}
+	_, err = c.send(ctx, merged, gr)
+	var responseErr *ResponseError
+	if err != nil && !errors.As(err, &responseErr) {
+		return nil, err
+	}
+	perRequest := make([][]GraphQLError, len(reqs))
+	for _, gerr := range gr.Errors {
+		i, stripped := splitErrorPath(gerr.Path, len(reqs))
+		if i < 0 {
+			for j := range perRequest {
+				perRequest[j] = append(perRequest[j], gerr)
+			}
+			continue
+		}
+		gerr.Path = stripped
+		perRequest[i] = append(perRequest[i], gerr)
+	}
+	errs := make([]error, len(reqs))
+	for i, gerrs := range perRequest {
+		if len(gerrs) > 0 {
+			errs[i] = &ResponseError{Errors: gerrs}
+		}
+	}
+	for i := range reqs {
+		if resps[i] == nil {
+			continue
+		}
+		prefix := mergePrefix(i)
+		part := make(map[string]json.RawMessage)
+		for key, value := range data {
+			if strings.HasPrefix(key, prefix) {
+				part[strings.TrimPrefix(key, prefix)] = value
+			}
+		}
+		b, err := json.Marshal(part)
+		if err != nil {
+			return nil, errors.Wrap(err, "encode merged data")
+		}
+		if err := c.decodeData(b, resps[i]); err != nil {
+			return nil, errors.Wrap(err, "decoding response")
+		}
+	}
+	return errs, nil
+}
+
+// mergePrefix is the alias prefix given to the root fields and variables
+// of the i-th merged request.
+func mergePrefix(i int) string {
+	return fmt.Sprintf("r%d_", i)
+}
+
+// splitErrorPath works out which merged request an error path belongs to
+// and returns the path as that request would have seen it.
+// It returns -1 if the path doesn't identify a request.
+func splitErrorPath(path []interface{}, n int) (int, []interface{}) {
+	if len(path) == 0 {
+		return -1, nil
+	}
+	key, ok := path[0].(string)
+	if !ok {
+		return -1, nil
+	}
+	for i := 0; i < n; i++ {
+		prefix := mergePrefix(i)
+		if strings.HasPrefix(key, prefix) {
+			stripped := append([]interface{}{strings.TrimPrefix(key, prefix)}, path[1:]...)
+			return i, stripped
+		}
+	}
+	return -1, nil
+}
+
+// mergeRequests builds a single request out of reqs.
+func mergeRequests(reqs []*Request) (*Request, error) {
+	if len(reqs) == 0 {
+		return nil, errors.New("graphql: merge: no requests")
+	}
+	var (
+		typ        string
+		varDefs    []token
+		selections []token
+		fragments  []token
+	)
+	merged := NewRequest("")
+	for i, req := range reqs {
+		req = req.snapshot()
+		if len(req.files) > 0 {
+			return nil, errors.New("graphql: merge: cannot merge requests with files")
+		}
+		doc, err := parseDocument(req.q)
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.operations) != 1 {
+			return nil, fmt.Errorf("graphql: merge: request %d must contain exactly one operation", i)
+		}
+		op := doc.operations[0]
+		if typ == "" {
+			typ = op.typ
+		} else if typ != op.typ {
+			return nil, fmt.Errorf("graphql: merge: cannot merge a %s with a %s", op.typ, typ)
+		}
+		if op.start != op.selStart {
+			next := op.start + 1
+			if op.name != "" {
+				next++
+			}
+			if op.varsEnd > 0 {
+				next = op.varsEnd + 1
+			}
+			if next != op.selStart {
+				return nil, fmt.Errorf("graphql: merge: request %d: operation directives are not supported", i)
+			}
+		}
+		fields, err := doc.rootFields(op.selStart, op.selEnd)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := mergePrefix(i)
+		tokens := make([]token, len(doc.tokens))
+		copy(tokens, doc.tokens)
+		for j := 0; j < len(tokens)-1; j++ {
+			next := tokens[j+1]
+			if next.kind != tokenName {
+				continue
+			}
+			if tokens[j].is("$") || (tokens[j].is("...") && next.text != "on") {
+				tokens[j+1].text = prefix + next.text
+			}
+		}
+		for _, frag := range doc.fragments {
+			tokens[frag.start+1].text = prefix + tokens[frag.start+1].text
+		}
+
+		varDefs = append(varDefs, tokens[op.varsStart:op.varsEnd]...)
+		aliased := make(map[int]bool, len(fields))
+		for _, j := range fields {
+			aliased[j] = true
+		}
+		for j := op.selStart + 1; j < op.selEnd-1; j++ {
+			t := tokens[j]
+			if aliased[j] {
+				if tokens[j+1].is(":") {
+					t.text = prefix + t.text
+				} else {
+					selections = append(selections,
+						token{kind: tokenName, text: prefix + t.text},
+						token{kind: tokenPunct, text: ":"},
+					)
+				}
+			}
+			selections = append(selections, t)
+		}
+		for _, frag := range doc.fragments {
+			fragments = append(fragments, tokens[frag.start:frag.end]...)
+		}
+
+		for key, value := range req.vars {
+			merged.Var(prefix+key, value)
+		}
+		for key, values := range req.Header {
+			if _, ok := merged.Header[key]; !ok {
+				merged.Header[key] = append([]string(nil), values...)
+			}
+		}
+	}
+	query := []token{{kind: tokenName, text: typ}}
+	if len(varDefs) > 0 {
+		query = append(query, token{kind: tokenPunct, text: "("})
+		query = append(query, varDefs...)
+		query = append(query, token{kind: tokenPunct, text: ")"})
+	}
+	query = append(query, token{kind: tokenPunct, text: "{"})
+	query = append(query, selections...)
+	query = append(query, token{kind: tokenPunct, text: "}"})
+	query = append(query, fragments...)
+	merged.q = joinTokens(query)
+	return merged, nil
+}
@@ -0,0 +1,100 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestMergeRequests(t *testing.T) {
+	is := is.New(t)
+
+	first := NewRequest(`query ($id: ID!) { user(id: $id) { name ...userFields } }
+		fragment userFields on User { email }`)
+	first.Var("id", "1")
+	second := NewRequest(`{ viewer: me { name } }`)
+
+	merged, err := mergeRequests([]*Request{first, second})
+	is.NoErr(err)
+	is.Equal(merged.q, `query($r0_id: ID!) { r0_user: user(id: $r0_id) { name ...r0_userFields } r1_viewer: me { name } } fragment r0_userFields on User { email }`)
+	is.Equal(merged.vars["r0_id"], "1")
+
+	_, err = mergeRequests([]*Request{NewRequest(`query { a }`), NewRequest(`mutation { b }`)})
+	is.Equal(err.Error(), "graphql: merge: cannot merge a mutation with a query")
+}
+
+func TestRunMerged(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var body struct {
+			Query string
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		is.Equal(body.Query, `query { r0_a: a r1_b: b }`)
+		io.WriteString(w, `{
+			"data": {"r0_a": "one", "r1_b": null},
+			"errors": [
+				{"message": "b failed", "path": ["r1_b"]},
+				{"message": "b failed again", "path": ["r1_b"]}
+			]
+		}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	listener := &recordingListener{}
+	client := NewClient(srv.URL, WithEventListener(listener))
+
+	var a struct{ A string }
+	var b struct{ B *string }
+	errs, err := client.RunMerged(ctx, []*Request{NewRequest(`{ a }`), NewRequest(`{ b }`)}, []interface{}{&a, &b})
+	is.NoErr(err)
+	is.Equal(calls, 1)
+	is.Equal(a.A, "one")
+	is.NoErr(errs[0])
+	is.Equal(errs[1].Error(), "graphql: b failed\ngraphql: b failed again")
+	var responseErr *ResponseError
+	is.True(errors.As(errs[1], &responseErr))
+	is.Equal(len(responseErr.Errors), 2)
+	is.Equal(responseErr.Errors[0].Path, []interface{}{"b"})
+	is.Equal(listener.events, []string{"RequestStarted", "AttemptStarted", "AttemptDone", "RequestDone"})
+}
+
+func TestRunMergedRetries(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"data": {"r0_a": "one", "r1_b": "two"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithRetry(2, func(int) time.Duration { return time.Millisecond }))
+	var a struct{ A string }
+	var b struct{ B string }
+	errs, err := client.RunMerged(ctx, []*Request{NewRequest(`{ a }`), NewRequest(`{ b }`)}, []interface{}{&a, &b})
+	is.NoErr(err)
+	is.Equal(calls, 2)
+	is.Equal(errs, []error{nil, nil})
+	is.Equal(a.A, "one")
+	is.Equal(b.B, "two")
+	is.Equal(client.Stats().Retries, int64(1))
+}
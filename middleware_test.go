@@ -0,0 +1,89 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestMiddleware(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Values("X-Trace"), []string{"client", "request"})
+		io.WriteString(w, `{"data":{"item":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next Runner) Runner {
+			return RunnerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+				order = append(order, name+" out")
+				req.Header.Add("X-Trace", name)
+				res, err := next.Execute(ctx, req)
+				order = append(order, name+" back")
+				return res, err
+			})
+		}
+	}
+	client := NewClient(srv.URL, WithMiddleware(tag("client")))
+
+	req := NewRequest("query { item }")
+	req.Use(tag("request"))
+	var resp struct {
+		Item string
+	}
+	_, err := client.Run(context.Background(), req, &resp)
+	is.NoErr(err)
+	is.Equal(resp.Item, "some data")
+	is.Equal(order, []string{"client out", "request out", "request back", "client back"})
+	is.Equal(len(req.Header["X-Trace"]), 0) // the attempt's copy was changed
+}
+
+func TestMiddlewareTransformsResponse(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"item":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	upper := func(next Runner) Runner {
+		return RunnerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			res, err := next.Execute(ctx, req)
+			if err != nil {
+				return res, err
+			}
+			body, err := json.Marshal(map[string]interface{}{
+				"data": map[string]interface{}{"item": res.GetString("item") + "!"},
+			})
+			if err != nil {
+				return nil, err
+			}
+			transformed := NewResponse(body)
+			transformed.Response = res.Response
+			return transformed, nil
+		})
+	}
+	client := NewClient(srv.URL)
+	req := NewRequest("query { item }")
+	req.Use(upper)
+	var resp struct {
+		Item string
+	}
+	res, err := client.Run(context.Background(), req, &resp)
+	is.NoErr(err)
+	is.Equal(resp.Item, "some data!")
+	is.Equal(res.GetString("item"), "some data!")
+
+	// other requests are left alone
+	_, err = client.Run(context.Background(), NewRequest("query { item }"), &resp)
+	is.NoErr(err)
+	is.Equal(resp.Item, "some data")
+}
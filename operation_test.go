@@ -0,0 +1,79 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestOperationName(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(NewRequest("query Items { items }").OperationName(), "Items")
+	is.Equal(NewRequest("{ items }").OperationName(), "")
+	is.Equal(NewRequest("query A { a } query B { b }").OperationName(), "")
+	req := NewRequest("query A { a } query B { b }")
+	req.Operation("B")
+	is.Equal(req.OperationName(), "B")
+}
+
+func TestOperationNameSent(t *testing.T) {
+	is := is.New(t)
+
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			sent = append(sent, r.URL.Query().Get("operationName"))
+		case strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/"):
+			sent = append(sent, r.FormValue("operationName"))
+		default:
+			var body struct {
+				OperationName *string
+			}
+			is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+			if body.OperationName == nil {
+				sent = append(sent, "<none>")
+			} else {
+				sent = append(sent, *body.OperationName)
+			}
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	var events []string
+	listener := operationRecorder{events: &events}
+	client := NewClient(srv.URL, WithEventListener(listener))
+	ctx := context.Background()
+	for _, transport := range []Transport{TransportJSON, TransportGET, TransportMultipart} {
+		req := NewRequest("query Items { items }")
+		req.Transport(transport)
+		_, err := client.Run(ctx, req, nil)
+		is.NoErr(err)
+	}
+	_, err := client.Run(ctx, NewRequest("{ items }"), nil)
+	is.NoErr(err)
+	req := NewRequest("query A { a } query B { b }")
+	req.Operation("B")
+	_, err = client.Run(ctx, req, nil)
+	is.NoErr(err)
+
+	is.Equal(sent, []string{"Items", "Items", "Items", "<none>", "B"})
+	is.Equal(events, []string{"Items", "Items", "Items", "", "B"})
+}
+
+type operationRecorder struct {
+	NopEventListener
+	events *[]string
+}
+
+func (r operationRecorder) RequestDone(e RequestDoneEvent) {
+	*r.events = append(*r.events, e.Operation)
+}
@@ -0,0 +1,35 @@
+package graphql
+
+import (
+	"context"
+	"time"
+)
+
+// defaultPingQuery is the operation Ping sends unless WithPingQuery says
+// otherwise. Every GraphQL server can answer it.
+const defaultPingQuery = `{ __typename }`
+
+// Ping checks that the GraphQL server is reachable by sending it a
+// minimal operation, and reports how long the round trip took.
+// The error is nil only if the server returned a successful GraphQL
+// response.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	_, err := c.Run(ctx, NewRequest(c.pingDocument()), nil)
+	return time.Since(start), err
+}
+
+// pingDocument returns the operation sent by Ping.
+func (c *Client) pingDocument() string {
+	if c.pingQuery == "" {
+		return defaultPingQuery
+	}
+	return c.pingQuery
+}
+
+// WithPingQuery sets the operation sent by Ping.
+func WithPingQuery(q string) ClientOption {
+	return func(client *Client) {
+		client.pingQuery = q
+	}
+}
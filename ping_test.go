@@ -0,0 +1,39 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestPing(t *testing.T) {
+	is := is.New(t)
+
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		query = body.Query
+		io.WriteString(w, `{"data":{"__typename":"Query"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	latency, err := NewClient(srv.URL).Ping(ctx)
+	is.NoErr(err)
+	is.True(latency > 0)
+	is.Equal(query, `{ __typename }`)
+
+	_, err = NewClient(srv.URL, WithPingQuery(`query Health { ok }`)).Ping(ctx)
+	is.NoErr(err)
+	is.Equal(query, `query Health { ok }`)
+}
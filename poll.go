@@ -0,0 +1,107 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Poll runs req every interval, sending the Result of each run on the
+// returned channel, until ctx is done, when the channel is closed.
+// Failed runs are sent too, with Err set, and polling carries on after
+// them, unless the client has been closed.
+//
+// The first run starts straight away, and each wait is timed from the
+// end of the previous run, so a slow server is never sent overlapping
+// requests. By default each wait is jittered by up to 10% either side of
+// interval, so that many processes polling the same server drift apart
+// rather than arriving together; see WithPollJitter.
+//
+// The receiver must keep up: the next run doesn't start until the last
+// Result has been received. An interval that isn't positive would have
+// the server polled without a pause, so Poll refuses it, sending a
+// single Result with an error before closing the channel.
+func (c *Client) Poll(ctx context.Context, req *Request, interval time.Duration, opts ...PollOption) <-chan Result {
+	p := &poller{jitter: 0.1}
+	for _, optionFunc := range opts {
+		optionFunc(p)
+	}
+	results := make(chan Result)
+	if interval <= 0 {
+		// polling without a pause would flood the server
+		go func() {
+			defer close(results)
+			select {
+			case results <- Result{Err: fmt.Errorf("graphql: poll interval must be positive, not %v", interval)}:
+			case <-ctx.Done():
+			}
+		}()
+		return results
+	}
+	go func() {
+		defer close(results)
+		for {
+			var result Result
+			result.Response, result.Err = c.Run(ctx, req, &result.Data)
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+			if result.Err == ErrClientClosed {
+				return
+			}
+			timer := time.NewTimer(p.wait(interval))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// poller holds the settings of Poll.
+type poller struct {
+	jitter float64
+}
+
+// wait returns how long to wait before the next run.
+func (p *poller) wait(interval time.Duration) time.Duration {
+	if p.jitter <= 0 {
+		return interval
+	}
+	spread := float64(interval) * p.jitter
+	return interval + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// PollOption are functions that are passed into Poll to modify how
+// often it runs the request.
+type PollOption func(*poller)
+
+// WithPollJitter sets how far each wait between runs may stray from the
+// interval, as a fraction of it: 0.1, the default, waits between 90% and
+// 110% of the interval. Zero waits exactly the interval. Fractions are
+// capped at 0.99, so that every wait has a pause, and negative ones are
+// taken as zero.
+func WithPollJitter(fraction float64) PollOption {
+	return func(p *poller) {
+		switch {
+		case fraction < 0:
+			fraction = 0
+		case fraction > maxPollJitter:
+			fraction = maxPollJitter
+		}
+		p.jitter = fraction
+	}
+}
+
+// maxPollJitter is the largest jitter WithPollJitter allows, which never
+// brings a wait below 1% of the interval.
+const maxPollJitter = 0.99
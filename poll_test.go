@@ -0,0 +1,97 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestPoll(t *testing.T) {
+	is := is.New(t)
+
+	// the second poll fails, and polling carries on after it
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n == 2 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"n":%d}}`, n)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL)
+	results := client.Poll(ctx, NewRequest("query { n }"), time.Millisecond)
+	result := <-results
+	is.NoErr(result.Err)
+	is.Equal(string(result.Data), `{"n":1}`)
+	result = <-results
+	is.True(result.Err != nil)
+	is.Equal(result.Response.StatusCode, http.StatusServiceUnavailable)
+	result = <-results
+	is.NoErr(result.Err)
+	is.Equal(string(result.Data), `{"n":3}`)
+	cancel()
+	for range results {
+		// drain until closed
+	}
+	_, ok := <-results
+	is.True(!ok)
+}
+
+func TestPollJitter(t *testing.T) {
+	is := is.New(t)
+
+	p := &poller{jitter: 0.1}
+	for i := 0; i < 100; i++ {
+		wait := p.wait(time.Second)
+		is.True(wait >= 900*time.Millisecond)
+		is.True(wait <= 1100*time.Millisecond)
+	}
+	WithPollJitter(0)(p)
+	is.Equal(p.wait(time.Second), time.Second)
+	WithPollJitter(-1)(p)
+	is.Equal(p.wait(time.Second), time.Second)
+
+	WithPollJitter(5)(p) // capped, so the server is never polled without a pause
+	for i := 0; i < 100; i++ {
+		is.True(p.wait(time.Second) >= 10*time.Millisecond)
+	}
+}
+
+func TestPollInterval(t *testing.T) {
+	is := is.New(t)
+
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprint(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL)
+	for _, results := range []<-chan Result{
+		client.Poll(ctx, NewRequest("query { n }"), 0),
+		client.Live(ctx, NewRequest("query { n }"), -time.Second),
+	} {
+		var errs []error
+		for result := range results {
+			errs = append(errs, result.Err)
+		}
+		is.Equal(len(errs), 1)
+		is.True(strings.HasPrefix(errs[0].Error(), "graphql: poll interval must be positive"))
+	}
+	is.Equal(calls.Load(), int64(0))
+}
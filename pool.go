@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	linkedlist "container/list"
+	"sync"
+)
+
+// TenantConfig returns the endpoint and options of the client for a
+// tenant, such as its credentials.
+type TenantConfig func(tenant string) (endpoint string, opts []ClientOption, err error)
+
+// ClientPool holds a client for each of many tenants with their own
+// endpoints, creating them as they're needed and keeping the most
+// recently used:
+//
+//	pool := graphql.NewClientPool(base, 100, func(tenant string) (string, []graphql.ClientOption, error) {
+//	    t, err := tenants.Lookup(tenant)
+//	    if err != nil {
+//	        return "", nil, err
+//	    }
+//	    return t.Endpoint, []graphql.ClientOption{graphql.WithHeader("Authorization", t.Token)}, nil
+//	})
+//	client, err := pool.For(tenantID)
+//
+// Tenant clients are derived from a base client with Client.With, so
+// they share its http.Client and connection pool unless their options
+// configure a transport of their own.
+type ClientPool struct {
+	base   *Client
+	size   int
+	config TenantConfig
+
+	mu      sync.Mutex
+	lru     *linkedlist.List // of *poolEntry, most recently used first
+	clients map[string]*linkedlist.Element
+}
+
+type poolEntry struct {
+	tenant string
+	client *Client
+}
+
+// NewClientPool makes a pool that keeps the clients of at most size
+// tenants, configured by config on top of the options of base. A size
+// of zero or less keeps every client.
+func NewClientPool(base *Client, size int, config TenantConfig) *ClientPool {
+	return &ClientPool{
+		base:    base,
+		size:    size,
+		config:  config,
+		lru:     linkedlist.New(),
+		clients: make(map[string]*linkedlist.Element),
+	}
+}
+
+// For returns the client for tenant, creating it if the pool doesn't
+// hold one. It returns the error from the pool's TenantConfig.
+//
+// When the pool is full the least recently used client is dropped. It
+// isn't closed, since it shares its connections with the other clients,
+// so requests already running with it are unaffected; callers should
+// get clients from the pool each time rather than keeping them.
+func (p *ClientPool) For(tenant string) (*Client, error) {
+	p.mu.Lock()
+	if elem, ok := p.clients[tenant]; ok {
+		p.lru.MoveToFront(elem)
+		p.mu.Unlock()
+		return elem.Value.(*poolEntry).client, nil
+	}
+	p.mu.Unlock()
+
+	endpoint, opts, err := p.config(tenant)
+	if err != nil {
+		return nil, err
+	}
+	client := p.base.With(opts...)
+	client.endpoint = endpoint
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elem, ok := p.clients[tenant]; ok {
+		// created by another caller in the meantime
+		p.lru.MoveToFront(elem)
+		return elem.Value.(*poolEntry).client, nil
+	}
+	p.clients[tenant] = p.lru.PushFront(&poolEntry{tenant: tenant, client: client})
+	for p.size > 0 && p.lru.Len() > p.size {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		delete(p.clients, oldest.Value.(*poolEntry).tenant)
+	}
+	return client, nil
+}
+
+// Remove drops the client for tenant, so the next call to For creates a
+// new one, for instance after the tenant's credentials change.
+func (p *ClientPool) Remove(tenant string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elem, ok := p.clients[tenant]; ok {
+		p.lru.Remove(elem)
+		delete(p.clients, tenant)
+	}
+}
+
+// Len returns the number of clients in the pool.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lru.Len()
+}
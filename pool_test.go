@@ -0,0 +1,64 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestClientPool(t *testing.T) {
+	is := is.New(t)
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.Path+" "+r.Header.Get("Authorization"))
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var configured []string
+	base := NewClient(srv.URL, WithMaxIdleConnsPerHost(10))
+	pool := NewClientPool(base, 2, func(tenant string) (string, []ClientOption, error) {
+		configured = append(configured, tenant)
+		if tenant == "unknown" {
+			return "", nil, errors.New("no such tenant")
+		}
+		return srv.URL + "/" + tenant, []ClientOption{WithHeader("Authorization", "token-"+tenant)}, nil
+	})
+
+	a, err := pool.For("a")
+	is.NoErr(err)
+	_, err = a.Run(ctx, NewRequest("{ a }"), nil)
+	is.NoErr(err)
+	is.Equal(got, []string{"/a token-a"})
+	is.True(a.httpClient == base.httpClient) // connections are shared
+
+	again, err := pool.For("a")
+	is.NoErr(err)
+	is.True(again == a)
+	_, err = pool.For("b")
+	is.NoErr(err)
+	_, err = pool.For("a") // a is now the most recently used
+	is.NoErr(err)
+	_, err = pool.For("c") // evicts b
+	is.NoErr(err)
+	is.Equal(pool.Len(), 2)
+	_, err = pool.For("b")
+	is.NoErr(err)
+	is.Equal(configured, []string{"a", "b", "c", "b"})
+
+	pool.Remove("b")
+	is.Equal(pool.Len(), 1)
+
+	_, err = pool.For("unknown")
+	is.Equal(err.Error(), "no such tenant")
+	is.Equal(pool.Len(), 1)
+}
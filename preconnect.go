@@ -0,0 +1,102 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Preconnect opens n connections to the server and leaves them idle in
+// the connection pool, so the first requests after the process starts
+// don't wait for TCP and TLS handshakes. It does so by sending n copies
+// of the Ping operation at the same time. The transport only keeps as
+// many idle connections as WithMaxIdleConnsPerHost allows, two by
+// default. There are no connections to open for a client created with
+// WithRunner, so it sends no pings.
+//
+// Each of the queries in prime is then run, as Run would, sent in full
+// along with its hash, so that a server supporting automatic persisted
+// queries stores it and later requests for it can be sent as the hash
+// alone; WithPersistedQueryRegistry records the hashes the server
+// accepts. The GraphQL errors the server returns for them, such as
+// missing variables, are ignored.
+//
+// The queries in prime are really executed by the server, so they
+// should be free of side effects: a mutation in prime is applied, once
+// for every process that starts.
+//
+// Preconnect returns the first error sending the requests, or an
+// OperationNotAllowedError, before anything is sent, if the Ping
+// operation or any query in prime isn't allowed by
+// WithAllowedOperations.
+func (c *Client) Preconnect(ctx context.Context, n int, prime ...string) error {
+	if n < 0 {
+		return fmt.Errorf("graphql: preconnect: n must not be negative, got %d", n)
+	}
+	if !c.state.begin() {
+		return ErrClientClosed
+	}
+	defer c.state.end()
+	ping := c.pingDocument()
+	for _, q := range append([]string{ping}, prime...) {
+		if err := c.checkAllowed(q); err != nil {
+			return err
+		}
+	}
+	if c.runner != nil {
+		n = 0
+	}
+	body, err := json.Marshal(map[string]string{"query": ping})
+	if err != nil {
+		return errors.Wrap(err, "encode body")
+	}
+	var (
+		wg        sync.WaitGroup
+		responses = make([]*http.Response, n)
+		errs      = make([]error, n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			r.Header.Set("Content-Type", "application/json; charset=utf-8")
+			r.Header.Set("Accept", "application/json; charset=utf-8")
+			c.setHeaders(ctx, r, NewRequest(""))
+			responses[i], errs[i] = c.do(ctx, r.WithContext(ctx))
+		}(i)
+	}
+	// the bodies are only read once every request has its response, so
+	// that no request can reuse the connection of another
+	wg.Wait()
+	for _, res := range responses {
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	for _, q := range prime {
+		req := NewRequest(q)
+		req.prime = true
+		var responseErr *ResponseError
+		if _, err := c.runRequest(ctx, req, nil); err != nil && !errors.As(err, &responseErr) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestPreconnect(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		mu     sync.Mutex
+		conns  = make(map[string]bool)
+		primed []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query      string
+			Extensions map[string]interface{}
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		conns[r.RemoteAddr] = true
+		if body.Extensions != nil {
+			primed = append(primed, body.Query)
+		}
+		mu.Unlock()
+		io.WriteString(w, `{"data":{"__typename":"Query"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithMaxIdleConnsPerHost(4), WithPersistedQueries())
+	is.NoErr(client.Preconnect(ctx, 3, "query Q { a }"))
+	is.Equal(len(conns), 3)
+	is.Equal(primed, []string{"query Q { a }"})
+
+	_, err := client.Run(ctx, NewRequest("{ a }"), nil)
+	is.NoErr(err)
+	is.Equal(len(conns), 3) // a warm connection was used
+}
+
+func TestPreconnectChecksAllowlist(t *testing.T) {
+	is := is.New(t)
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithAllowedOperations(defaultPingQuery))
+	err := client.Preconnect(context.Background(), 2, "query Q { a }")
+	var notAllowed *OperationNotAllowedError
+	is.True(errors.As(err, &notAllowed))
+	is.Equal(atomic.LoadInt32(&calls), int32(0)) // nothing was sent
+
+	client = NewClient(srv.URL, WithAllowedOperations("query Q { a }"))
+	err = client.Preconnect(context.Background(), 2)
+	is.True(errors.As(err, &notAllowed)) // the ping isn't allowed
+	is.Equal(atomic.LoadInt32(&calls), int32(0))
+}
+
+func TestPreconnectNegative(t *testing.T) {
+	is := is.New(t)
+
+	err := NewClient("http://api.test/graphql").Preconnect(context.Background(), -1)
+	is.Equal(err.Error(), "graphql: preconnect: n must not be negative, got -1")
+}
+
+func TestPreconnectRegistry(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		bodies = append(bodies, body.Query)
+		mu.Unlock()
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	reg := NewPersistedQueryRegistry()
+	client := NewClient(srv.URL, WithPersistedQueryRegistry(reg))
+	is.NoErr(client.Preconnect(context.Background(), 0, "query Q { a }"))
+	is.True(reg.Known(srv.URL, hashDocument("query Q { a }")))
+
+	_, err := client.Run(context.Background(), NewRequest("query Q { a }"), nil)
+	is.NoErr(err)
+	is.Equal(bodies, []string{"query Q { a }", ""}) // sent as the hash alone
+}
+
+func TestPreconnectRunner(t *testing.T) {
+	is := is.New(t)
+
+	var queries []string
+	client := NewClient("http://api.test/graphql", WithRunner(RunnerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		queries = append(queries, req.Query())
+		return NewResponse([]byte(`{"data":{}}`)), nil
+	})))
+	is.NoErr(client.Preconnect(context.Background(), 3, "query Q { a }"))
+	is.Equal(queries, []string{"query Q { a }"}) // no pings, and the prime went to the runner
+}
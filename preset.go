@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"sort"
+	"sync"
+)
+
+// Preset is a bundle of client options for a particular GraphQL service
+// or vendor, such as the headers it expects, the error codes it uses for
+// transient failures, and how it reports rate limits. Presets let that
+// knowledge live in packages of their own, which register themselves
+// with RegisterPreset when imported, rather than in this one:
+//
+//	import _ "example.com/graphql-presets/github"
+//
+//	preset, ok := graphql.LookupPreset("github")
+//	if !ok {
+//	    log.Fatal("no github preset")
+//	}
+//	client := graphql.NewClient(endpoint, graphql.WithPreset(preset))
+type Preset interface {
+	// Options returns the options the preset applies to a client.
+	Options() []ClientOption
+}
+
+// PresetFunc is a function that returns the options of a Preset.
+type PresetFunc func() []ClientOption
+
+// Options calls f.
+func (f PresetFunc) Options() []ClientOption {
+	return f()
+}
+
+// WithPreset applies the options of preset. Options given after it
+// override those it sets.
+func WithPreset(preset Preset) ClientOption {
+	return func(client *Client) {
+		for _, optionFunc := range preset.Options() {
+			optionFunc(client)
+		}
+	}
+}
+
+var (
+	presetsMu sync.RWMutex
+	presets   = make(map[string]Preset)
+)
+
+// RegisterPreset makes a preset available by name to LookupPreset. It is
+// intended to be called from the init function of the package providing
+// the preset, and panics if preset is nil or the name is already taken.
+func RegisterPreset(name string, preset Preset) {
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+	if preset == nil {
+		panic("graphql: RegisterPreset preset is nil")
+	}
+	if _, dup := presets[name]; dup {
+		panic("graphql: RegisterPreset called twice for preset " + name)
+	}
+	presets[name] = preset
+}
+
+// LookupPreset returns the preset registered under name.
+func LookupPreset(name string) (Preset, bool) {
+	presetsMu.RLock()
+	defer presetsMu.RUnlock()
+	preset, ok := presets[name]
+	return preset, ok
+}
+
+// Presets returns the sorted names of the registered presets.
+func Presets() []string {
+	presetsMu.RLock()
+	defer presetsMu.RUnlock()
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,52 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestPreset(t *testing.T) {
+	is := is.New(t)
+
+	var agents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Header.Get("User-Agent")+" "+r.Header.Get("X-Vendor"))
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	RegisterPreset("test-vendor", PresetFunc(func() []ClientOption {
+		return []ClientOption{
+			WithUserAgent("vendor-agent"),
+			WithHeader("X-Vendor", "yes"),
+		}
+	}))
+	defer func() {
+		presetsMu.Lock()
+		delete(presets, "test-vendor")
+		presetsMu.Unlock()
+	}()
+	is.Equal(Presets(), []string{"test-vendor"})
+	_, ok := LookupPreset("missing")
+	is.True(!ok)
+	preset, ok := LookupPreset("test-vendor")
+	is.True(ok)
+
+	ctx := context.Background()
+	_, err := NewClient(srv.URL, WithPreset(preset)).Run(ctx, NewRequest("{ a }"), nil)
+	is.NoErr(err)
+	// later options override the preset's
+	_, err = NewClient(srv.URL, WithPreset(preset), WithUserAgent("mine")).Run(ctx, NewRequest("{ a }"), nil)
+	is.NoErr(err)
+	is.Equal(agents, []string{"vendor-agent yes", "mine yes"})
+
+	defer func() {
+		is.True(recover() != nil) // registering a name twice panics
+	}()
+	RegisterPreset("test-vendor", preset)
+}
@@ -0,0 +1,49 @@
+package graphql
+
+import "sort"
+
+// Priority is the relative importance of a Request. Queues and RunAll
+// start higher priority requests ahead of lower priority ones.
+type Priority int
+
+// Common priorities. Any other value may be used too.
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// Priority sets the priority of the request. If the client was created
+// with WithPriorityHeader the priority is also sent to the server.
+func (req *Request) Priority(p Priority) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.priority = p
+}
+
+func (req *Request) getPriority() Priority {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	return req.priority
+}
+
+// WithPriorityHeader sends the priority of each request, as a decimal
+// number, in the named header for servers that honor it.
+func WithPriorityHeader(name string) ClientOption {
+	return func(client *Client) {
+		client.priorityHeader = name
+	}
+}
+
+// byPriority returns the indexes of reqs, highest priority first and
+// otherwise in their original order.
+func byPriority(reqs []*Request) []int {
+	order := make([]int, len(reqs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return reqs[order[a]].getPriority() > reqs[order[b]].getPriority()
+	})
+	return order
+}
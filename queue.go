@@ -0,0 +1,271 @@
+package graphql
+
+import (
+	"container/heap"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrQueueFull is returned by Queue.Enqueue when the queue has no room
+// for another request.
+var ErrQueueFull = errors.New("graphql: queue is full")
+
+// ErrQueueClosed is returned by Queue.Enqueue once the queue has been
+// closed.
+var ErrQueueClosed = errors.New("graphql: queue is closed")
+
+// QueueStore is a persistence hook for a Queue. Save is called when a
+// request is enqueued and Remove once it has either succeeded or been
+// given up on, so requests still in the store after a restart are those
+// that never completed and can be enqueued again.
+type QueueStore interface {
+	Save(req *Request) error
+	Remove(req *Request) error
+}
+
+// Queue runs requests in the background on a pool of workers, retrying
+// failed ones (on top of any retries the client itself makes), so
+// callers that don't need the response (telemetry mutations and the
+// like) don't have to wait for it.
+type Queue struct {
+	client *Client
+
+	workers     int
+	size        int
+	maxAttempts int
+	backoff     func(attempt int) time.Duration
+	store       QueueStore
+	onError     func(req *Request, err error)
+
+	// mu guards jobs, which is kept in priority order, and closed.
+	mu     sync.Mutex
+	ready  *sync.Cond
+	jobs   jobHeap
+	seq    uint64
+	closed bool
+	wg     sync.WaitGroup
+	// stop is closed by Close to cut short waits between retries.
+	stop chan struct{}
+}
+
+// NewQueue makes a new Queue that runs requests with this client.
+// The workers start immediately; call Close, or close the client, to
+// stop them.
+func (c *Client) NewQueue(opts ...QueueOption) *Queue {
+	q := &Queue{
+		client:      c,
+		workers:     4,
+		size:        100,
+		maxAttempts: 3,
+		backoff:     exponentialBackoff,
+		onError:     func(*Request, error) {},
+	}
+	for _, optionFunc := range opts {
+		optionFunc(q)
+	}
+	q.ready = sync.NewCond(&q.mu)
+	q.stop = make(chan struct{})
+	if !c.state.addQueue(q) {
+		q.closed = true
+		return q
+	}
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Enqueue adds the request to the queue without waiting for it to run.
+// It returns ErrQueueFull rather than block when the queue is full.
+// Requests with a higher Priority are run ahead of those already
+// waiting with a lower one.
+func (q *Queue) Enqueue(req *Request) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if len(q.jobs) >= q.size {
+		return ErrQueueFull
+	}
+	if q.store != nil {
+		if err := q.store.Save(req); err != nil {
+			return errors.Wrap(err, "save request")
+		}
+	}
+	q.seq++
+	heap.Push(&q.jobs, job{req: req, priority: req.getPriority(), seq: q.seq})
+	q.ready.Signal()
+	return nil
+}
+
+// Close stops the queue accepting requests and waits for the requests
+// already queued to finish. Requests that are waiting to be retried are
+// given up on rather than left to wait out their backoff; they are
+// passed to the error handler but kept in the QueueStore, so they can be
+// enqueued again.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.stop)
+	}
+	q.ready.Broadcast()
+	q.mu.Unlock()
+	q.wg.Wait()
+	q.client.state.removeQueue(q)
+}
+
+// next waits for the next request to run, returning nil once the queue
+// is closed and empty.
+func (q *Queue) next() *Request {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.jobs) == 0 {
+		if q.closed {
+			return nil
+		}
+		q.ready.Wait()
+	}
+	return heap.Pop(&q.jobs).(job).req
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for req := q.next(); req != nil; req = q.next() {
+		done, err := q.run(req)
+		if err != nil {
+			q.onError(req, err)
+		}
+		if q.store != nil && done {
+			if err := q.store.Remove(req); err != nil {
+				q.onError(req, errors.Wrap(err, "remove request"))
+			}
+		}
+	}
+}
+
+// run executes the request, retrying it until it succeeds or has been
+// attempted maxAttempts times. Requests are only retried if they are
+// safe to repeat and failed in a way the client would retry, so a
+// mutation the server may have applied isn't sent twice. done is false
+// if the queue was closed while the request was waiting to be retried,
+// or the client was closed before it could be sent.
+func (q *Queue) run(req *Request) (done bool, err error) {
+	// every attempt must carry the same idempotency key
+	attemptReq := q.client.withIdempotencyKey(req)
+	rewind, rewindable := attemptReq.fileRewinder()
+	retryable := rewindable && attemptReq.safeToRetry()
+	ctx := context.Background()
+	for attempt := 1; ; attempt++ {
+		var res *Response
+		if res, err = q.client.runRequest(ctx, attemptReq, nil); err == nil {
+			return true, nil
+		}
+		if err == ErrClientClosed {
+			// never sent, so it stays in the store to be enqueued again
+			return false, err
+		}
+		var httpRes *http.Response
+		if res != nil {
+			httpRes = res.Response
+		}
+		if attempt >= q.maxAttempts || !retryable || !q.client.isRetryable(ctx, httpRes, err) {
+			return true, err
+		}
+		q.client.logf("queue: attempt %d failed, retrying: %s", attempt, err)
+		q.client.stats.retries.Add(1)
+		delay := q.backoff(attempt)
+		q.client.events.RetryScheduled(RetryScheduledEvent{Request: req, Attempt: attempt, Err: err, Delay: delay})
+		timer := time.NewTimer(delay)
+		select {
+		case <-q.stop:
+			timer.Stop()
+			return false, err
+		case <-timer.C:
+		}
+		if err := rewind(); err != nil {
+			return true, errors.Wrap(err, "rewind files")
+		}
+	}
+}
+
+// job is a request waiting in a Queue.
+type job struct {
+	req      *Request
+	priority Priority
+	// seq keeps requests of the same priority in the order they were
+	// enqueued.
+	seq uint64
+}
+
+// jobHeap is a heap.Interface of jobs, highest priority first.
+type jobHeap []job
+
+func (h jobHeap) Len() int { return len(h) }
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h jobHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *jobHeap) Push(x interface{}) { *h = append(*h, x.(job)) }
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	j := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return j
+}
+
+// QueueOption are functions that are passed into NewQueue to
+// modify the behaviour of the Queue.
+type QueueOption func(*Queue)
+
+// WithQueueWorkers sets the number of requests the queue runs at once.
+// The default is 4.
+func WithQueueWorkers(n int) QueueOption {
+	return func(q *Queue) {
+		q.workers = n
+	}
+}
+
+// WithQueueSize sets how many requests may be waiting in the queue
+// before Enqueue returns ErrQueueFull. The default is 100.
+func WithQueueSize(n int) QueueOption {
+	return func(q *Queue) {
+		q.size = n
+	}
+}
+
+// WithQueueRetries sets the maximum number of times each request is
+// attempted, and how long to wait before each retry. A nil backoff keeps
+// the default exponential backoff. The default is 3 attempts.
+func WithQueueRetries(maxAttempts int, backoff func(attempt int) time.Duration) QueueOption {
+	return func(q *Queue) {
+		q.maxAttempts = maxAttempts
+		if backoff != nil {
+			q.backoff = backoff
+		}
+	}
+}
+
+// WithQueueStore sets a QueueStore used to persist queued requests.
+func WithQueueStore(store QueueStore) QueueOption {
+	return func(q *Queue) {
+		q.store = store
+	}
+}
+
+// WithQueueErrorHandler sets a function that is called with requests
+// that could not be run successfully.
+func WithQueueErrorHandler(fn func(req *Request, err error)) QueueOption {
+	return func(q *Queue) {
+		q.onError = fn
+	}
+}
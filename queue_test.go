@@ -0,0 +1,232 @@
+package graphql
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	saved   int
+	pending map[*Request]bool
+}
+
+func (s *memoryStore) Save(req *Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved++
+	s.pending[req] = true
+	return nil
+}
+
+func (s *memoryStore) Remove(req *Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, req)
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	is := is.New(t)
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, `Bad Gateway`)
+			return
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	store := &memoryStore{pending: make(map[*Request]bool)}
+	var failures int32
+	q := client.NewQueue(
+		WithQueueWorkers(1),
+		WithQueueRetries(2, func(int) time.Duration { return time.Millisecond }),
+		WithQueueStore(store),
+		WithQueueErrorHandler(func(req *Request, err error) {
+			atomic.AddInt32(&failures, 1)
+		}),
+	)
+	for i := 0; i < 2; i++ {
+		req := NewRequest("mutation {}")
+		req.Idempotent()
+		is.NoErr(q.Enqueue(req))
+	}
+	eventually(t, func() bool { return atomic.LoadInt32(&calls) == 3 }) // first request retried once
+	q.Close()
+
+	is.Equal(atomic.LoadInt32(&failures), int32(0))
+	is.Equal(store.saved, 2)
+	is.Equal(len(store.pending), 0)
+	is.Equal(q.Enqueue(NewRequest("mutation {}")), ErrQueueClosed)
+}
+
+func TestQueueGivesUp(t *testing.T) {
+	is := is.New(t)
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	failed := make(chan error)
+	q := client.NewQueue(
+		WithQueueRetries(3, func(int) time.Duration { return time.Millisecond }),
+		WithQueueErrorHandler(func(req *Request, err error) {
+			failed <- err
+		}),
+	)
+	req := NewRequest("mutation {}")
+	req.Idempotent()
+	is.NoErr(q.Enqueue(req))
+	is.True(<-failed != nil)
+	q.Close()
+
+	is.Equal(atomic.LoadInt32(&calls), int32(3))
+}
+
+func TestQueueRetriesOnlySafeRequests(t *testing.T) {
+	is := is.New(t)
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Query().Get("status") == "503" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"errors":[{"message":"nope"}]}`)
+	}))
+	defer srv.Close()
+
+	for _, test := range []struct {
+		name     string
+		endpoint string
+		req      func() *Request
+	}{
+		{"mutation", srv.URL + "?status=503", func() *Request { return NewRequest("mutation {}") }},
+		{"graphql error", srv.URL, func() *Request {
+			req := NewRequest("mutation {}")
+			req.Idempotent()
+			return req
+		}},
+	} {
+		atomic.StoreInt32(&calls, 0)
+		client := NewClient(test.endpoint)
+		var lastErr error
+		q := client.NewQueue(
+			WithQueueRetries(3, func(int) time.Duration { return time.Millisecond }),
+			WithQueueErrorHandler(func(req *Request, err error) {
+				lastErr = err
+			}),
+		)
+		is.NoErr(q.Enqueue(test.req()))
+		q.Close()
+		is.Equal(atomic.LoadInt32(&calls), int32(1)) // test.name
+		is.True(lastErr != nil)                      // test.name
+	}
+}
+
+func TestQueueCloseStopsRetries(t *testing.T) {
+	is := is.New(t)
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	store := &memoryStore{pending: make(map[*Request]bool)}
+	var lastErr error
+	q := client.NewQueue(
+		WithQueueRetries(3, func(int) time.Duration { return time.Hour }),
+		WithQueueStore(store),
+		WithQueueErrorHandler(func(req *Request, err error) {
+			lastErr = err
+		}),
+	)
+	req := NewRequest("mutation {}")
+	req.Idempotent()
+	is.NoErr(q.Enqueue(req))
+	eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 })
+	start := time.Now()
+	q.Close()
+
+	is.True(time.Since(start) < time.Second) // didn't wait out the backoff
+	is.True(lastErr != nil)
+	is.Equal(len(store.pending), 1) // kept to be enqueued again
+}
+
+// eventually waits for cond to become true, failing the test if it
+// doesn't within a second.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	is := is.New(t)
+
+	release := make(chan struct{})
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		order = append(order, r.Header.Get("X-Name")+":"+r.Header.Get("X-Priority"))
+		mu.Unlock()
+		if r.Header.Get("X-Name") == "first" {
+			<-release
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithPriorityHeader("X-Priority"))
+	q := client.NewQueue(WithQueueWorkers(1))
+	is.NoErr(q.Enqueue(NewRequest("mutation {}").WithHeader("X-Name", "first")))
+	for {
+		mu.Lock()
+		started := len(order) == 1
+		mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for _, p := range []struct {
+		name     string
+		priority Priority
+	}{{"low", PriorityLow}, {"normal", PriorityNormal}, {"high", PriorityHigh}, {"high2", PriorityHigh}} {
+		req := NewRequest("mutation {}").WithHeader("X-Name", p.name)
+		req.Priority(p.priority)
+		is.NoErr(q.Enqueue(req))
+	}
+	close(release)
+	q.Close()
+
+	is.Equal(order, []string{"first:0", "high:1", "high2:1", "normal:0", "low:-1"})
+}
@@ -0,0 +1,107 @@
+package graphql
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit is the state of the rate limit a server applies to the
+// client, as the server reported it with a response.
+type RateLimit struct {
+	// Limit is the number of requests, or other units, allowed in each
+	// window.
+	Limit int64
+	// Remaining is how many are left in the current window.
+	Remaining int64
+	// Reset is when the current window ends, or the zero time if the
+	// server didn't say.
+	Reset time.Time
+}
+
+// RateLimitParser reads the rate limit from the headers of a response,
+// reporting false if they don't give one.
+type RateLimitParser func(h http.Header) (RateLimit, bool)
+
+// WithRateLimitParser sets the parser used to fill in Response.RateLimit,
+// for servers that report their limits in their own headers. The
+// default is ParseRateLimitHeaders. A nil parser leaves RateLimit nil.
+func WithRateLimitParser(p RateLimitParser) ClientOption {
+	return func(client *Client) {
+		client.rateLimitParser = p
+	}
+}
+
+// ParseRateLimitHeaders reads the rate limit from the headers standardised
+// by the IETF, either RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset or the single RateLimit header of later drafts, or else
+// from the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset
+// headers many servers use. Resets are read as a number of seconds from
+// now, unless they are so large they can only be a Unix time.
+func ParseRateLimitHeaders(h http.Header) (RateLimit, bool) {
+	return parseRateLimitHeaders(h, time.Now())
+}
+
+func parseRateLimitHeaders(h http.Header, now time.Time) (RateLimit, bool) {
+	fields := map[string]string{}
+	if v := h.Get("RateLimit"); v != "" {
+		// such as limit=100, remaining=50, reset=30
+		for _, item := range strings.Split(v, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+			if ok {
+				fields[strings.ToLower(key)] = value
+			}
+		}
+	}
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-"} {
+		for _, name := range []string{"limit", "remaining", "reset"} {
+			if v := h.Get(prefix + name); v != "" && fields[name] == "" {
+				fields[name] = v
+			}
+		}
+	}
+	var rl RateLimit
+	limit, hasLimit := parseRateLimitNumber(fields["limit"])
+	remaining, hasRemaining := parseRateLimitNumber(fields["remaining"])
+	if !hasLimit && !hasRemaining {
+		return rl, false
+	}
+	rl.Limit, rl.Remaining = limit, remaining
+	if reset, ok := parseRateLimitNumber(fields["reset"]); ok {
+		if reset > unixTimeThreshold {
+			rl.Reset = time.Unix(reset, 0)
+		} else {
+			rl.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return rl, true
+}
+
+// unixTimeThreshold separates resets given as Unix times from those
+// given in seconds: no window is longer than the thirty years it
+// stands for.
+const unixTimeThreshold = 1e9
+
+// parseRateLimitNumber parses a header value such as 100, or 100;w=60
+// with the quota policy some servers add.
+func parseRateLimitNumber(v string) (int64, bool) {
+	v, _, _ = strings.Cut(v, ";")
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseRateLimit reads the rate limit from res with the client's parser.
+func (c *Client) parseRateLimit(res *http.Response) *RateLimit {
+	if res == nil || c.rateLimitParser == nil {
+		return nil
+	}
+	rl, ok := c.rateLimitParser(res.Header)
+	if !ok {
+		return nil
+	}
+	return &rl
+}
@@ -0,0 +1,90 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestResponseRateLimit(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Reset", "1767225600")
+		io.WriteString(w, `{"data":{"item":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	res, err := NewClient(srv.URL, WithoutResponseHeaders()).Run(ctx, NewRequest("query { item }"), nil)
+	is.NoErr(err)
+	is.Equal(res.RateLimit, &RateLimit{Limit: 5000, Remaining: 4999, Reset: time.Unix(1767225600, 0)})
+	is.Equal(len(res.Header), 0)
+
+	custom := func(h http.Header) (RateLimit, bool) {
+		return RateLimit{Remaining: 7}, true
+	}
+	res, err = NewClient(srv.URL, WithRateLimitParser(custom)).Run(ctx, NewRequest("query { item }"), nil)
+	is.NoErr(err)
+	is.Equal(res.RateLimit, &RateLimit{Remaining: 7})
+
+	res, err = NewClient(srv.URL, WithRateLimitParser(nil)).Run(ctx, NewRequest("query { item }"), nil)
+	is.NoErr(err)
+	is.Equal(res.RateLimit, nil)
+}
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   RateLimit
+		ok     bool
+	}{
+		{
+			name:   "none",
+			header: http.Header{},
+		},
+		{
+			name: "separate",
+			header: http.Header{
+				"Ratelimit-Limit":     {"100;w=60"},
+				"Ratelimit-Remaining": {"40"},
+				"Ratelimit-Reset":     {"30"},
+			},
+			want: RateLimit{Limit: 100, Remaining: 40, Reset: now.Add(30 * time.Second)},
+			ok:   true,
+		},
+		{
+			name:   "combined",
+			header: http.Header{"Ratelimit": {"limit=10, remaining=0, reset=5"}},
+			want:   RateLimit{Limit: 10, Reset: now.Add(5 * time.Second)},
+			ok:     true,
+		},
+		{
+			name: "standard preferred",
+			header: http.Header{
+				"Ratelimit-Remaining":   {"1"},
+				"X-Ratelimit-Remaining": {"2"},
+				"X-Ratelimit-Limit":     {"3"},
+			},
+			want: RateLimit{Limit: 3, Remaining: 1},
+			ok:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			got, ok := parseRateLimitHeaders(tt.header, now)
+			is.Equal(ok, tt.ok)
+			is.Equal(got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Response is the response to a request run by Run. It gives untyped
+// access to what the server returned, and embeds the final HTTP
+// response for the details of the exchange, such as its TLS state,
+// protocol version and trailers. The HTTP response's body has already
+// been read and closed; reading it again returns io.EOF straight away.
+type Response struct {
+	*http.Response
+
+	// RateLimit is the rate limit the server reported in the response's
+	// headers, or nil if it reported none. It is read before
+	// WithResponseHeaders drops any headers.
+	RateLimit *RateLimit
+
+	// Duration is how long Run took, including any retries and the
+	// waits between them.
+	Duration time.Duration
+	// Attempts is how many attempts Run made, 1 unless the request was
+	// retried.
+	Attempts int
+	// Endpoint is the URL that served the response, without any query
+	// string or credentials, which differs from the client's endpoint
+	// when the request was redirected, or when a Router, ClientPool or
+	// custom Runner chose where to send it. It is empty if a Runner's
+	// response didn't say.
+	Endpoint string
+	// Cached is set if the response came from an HTTP cache, such as a
+	// CDN in front of the server, rather than from the server itself, as
+	// told by an Age header or a Cache-Status, X-Cache or CF-Cache-Status
+	// header reporting a hit.
+	Cached bool
+
+	// body is the response body, decompressed.
+	body []byte
+	// rawBody is the body exactly as received, kept by WithKeepRawBody.
+	rawBody    []byte
+	useNumber  bool
+	costParser CostParser
+
+	once    sync.Once
+	decoded interface{}
+}
+
+// Get returns the value at path in the response data, or nil if there
+// is none. The path is a list of object keys and array indexes separated
+// by dots, such as "viewer.repositories.nodes.0.name". Objects are
+// returned as map[string]interface{} and arrays as []interface{}.
+// Numbers are float64, or json.Number if the client was created with
+// WithNumbers. Get may be called on a nil Response.
+func (r *Response) Get(path string) interface{} {
+	if path == "" {
+		return r.Query("data")
+	}
+	return r.Query("data." + path)
+}
+
+// Query returns the value at path in the whole response body, so that
+// errors and extensions can be picked out as well as data:
+//
+//	res.Query("errors.0.message")
+//	res.Query("extensions.cost.requestedQueryCost")
+//
+// Paths are as for Get, with two additions in the style of GJSON: a #
+// in place of an array index gives the length of the array, and a # in
+// the middle of a path collects the rest of the path from every element,
+// so "errors.#.message" is a []interface{} of every error message. A
+// dot that is part of a key is escaped with a backslash.
+func (r *Response) Query(path string) interface{} {
+	if r == nil {
+		return nil
+	}
+	r.once.Do(func() {
+		if len(bytes.TrimSpace(r.body)) == 0 {
+			return
+		}
+		dec := json.NewDecoder(bytes.NewReader(r.body))
+		if r.useNumber {
+			dec.UseNumber()
+		}
+		// a body that isn't JSON leaves decoded nil, so every path is
+		// missing
+		_ = dec.Decode(&r.decoded)
+	})
+	if path == "" {
+		return r.decoded
+	}
+	return lookupPath(r.decoded, splitPath(path))
+}
+
+// Bytes returns the response body exactly as it was received, for
+// audit logging or checking a signature over the payload. It returns nil
+// unless the client was created with WithKeepRawBody.
+func (r *Response) Bytes() []byte {
+	if r == nil {
+		return nil
+	}
+	return r.rawBody
+}
+
+// WithKeepRawBody keeps the body of each response as it was received,
+// before decompression or decoding, for Response.Bytes to return.
+//
+// Unless WithAcceptEncoding is used the http.Transport may already have
+// decompressed the body, so Bytes returns it as the transport gave it.
+// Error responses are kept only up to WithMaxErrorBodySize.
+func WithKeepRawBody() ClientOption {
+	return func(client *Client) {
+		client.keepRawBody = true
+	}
+}
+
+// Redirects returns the redirect responses that were followed to reach
+// the final response, oldest first. Their bodies have been closed.
+func (r *Response) Redirects() []*http.Response {
+	if r == nil || r.Response == nil {
+		return nil
+	}
+	var redirects []*http.Response
+	for req := r.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		redirects = append([]*http.Response{req.Response}, redirects...)
+	}
+	return redirects
+}
+
+// GetString returns the string at path in the response data, or "" if
+// there is no string there.
+func (r *Response) GetString(path string) string {
+	s, _ := r.Get(path).(string)
+	return s
+}
+
+// GetInt returns the integer at path in the response data, or 0 if there
+// is no integer there.
+func (r *Response) GetInt(path string) int64 {
+	switch v := r.Get(path).(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+	}
+	return 0
+}
+
+// GetSlice returns the array at path in the response data, or nil if
+// there is no array there.
+func (r *Response) GetSlice(path string) []interface{} {
+	s, _ := r.Get(path).([]interface{})
+	return s
+}
+
+// servedBy returns the URL res came from, without its query string or
+// any credentials.
+func servedBy(res *http.Response) string {
+	if res.Request == nil || res.Request.URL == nil {
+		return ""
+	}
+	u := *res.Request.URL
+	u.User = nil
+	u.RawQuery, u.ForceQuery, u.Fragment, u.RawFragment = "", false, "", ""
+	return u.String()
+}
+
+// fromCache reports whether the headers h say a response came from an
+// HTTP cache.
+func fromCache(h http.Header) bool {
+	if h.Get("Age") != "" {
+		return true
+	}
+	for _, name := range []string{"X-Cache", "Cf-Cache-Status"} {
+		if strings.Contains(strings.ToLower(h.Get(name)), "hit") {
+			return true
+		}
+	}
+	// such as ExampleCache; hit, or ExampleCache; fwd=uri-miss
+	for _, value := range h.Values("Cache-Status") {
+		for _, param := range strings.Split(value, ";")[1:] {
+			if strings.TrimSpace(strings.ToLower(param)) == "hit" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitPath splits a path into its keys at unescaped dots.
+func splitPath(path string) []string {
+	var (
+		keys []string
+		key  strings.Builder
+	)
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			key.WriteByte('.')
+			i++
+		case path[i] == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(path[i])
+		}
+	}
+	return append(keys, key.String())
+}
+
+// lookupPath returns the value found by following keys from value.
+func lookupPath(value interface{}, keys []string) interface{} {
+	for i, key := range keys {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			if key == "#" {
+				if i == len(keys)-1 {
+					return len(v)
+				}
+				var values []interface{}
+				for _, elem := range v {
+					if found := lookupPath(elem, keys[i+1:]); found != nil {
+						values = append(values, found)
+					}
+				}
+				return values
+			}
+			n, err := strconv.Atoi(key)
+			if err != nil || n < 0 || n >= len(v) {
+				return nil
+			}
+			value = v[n]
+		default:
+			return nil
+		}
+	}
+	return value
+}
@@ -0,0 +1,209 @@
+package graphql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestResponseGet(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"viewer":{"login":"matryer","repositories":{"totalCount":9007199254740993,"nodes":[{"name":"is"},{"name":"graphql"}]}}}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	res, err := NewClient(srv.URL).Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(res.StatusCode, http.StatusOK)
+	is.Equal(res.GetString("viewer.login"), "matryer")
+	is.Equal(res.GetString("viewer.repositories.nodes.1.name"), "graphql")
+	is.Equal(len(res.GetSlice("viewer.repositories.nodes")), 2)
+	is.Equal(res.Get("viewer.repositories.nodes.2"), nil)
+	is.Equal(res.Get("viewer.nope.deeper"), nil)
+	is.Equal(res.GetString("viewer.repositories"), "") // not a string
+	is.Equal(res.GetInt("viewer.login"), int64(0))
+
+	res, err = NewClient(srv.URL, WithNumbers()).Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(res.Get("viewer.repositories.totalCount"), json.Number("9007199254740993"))
+	is.Equal(res.GetInt("viewer.repositories.totalCount"), int64(9007199254740993))
+
+	var nilResponse *Response
+	is.Equal(nilResponse.GetString("viewer.login"), "")
+}
+
+func TestResponseQuery(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"a.b":1},"errors":[{"message":"first","path":["x"]},{"message":"second"}],"extensions":{"cost":{"requested":3}}}`)
+	}))
+	defer srv.Close()
+
+	res, err := NewClient(srv.URL).Run(context.Background(), NewRequest("query {}"), nil)
+	is.Equal(err.Error(), "graphql: first\ngraphql: second")
+	is.Equal(res.Query("errors.1.message"), "second")
+	is.Equal(res.Query("errors.#"), 2)
+	is.Equal(res.Query("errors.#.message"), []interface{}{"first", "second"})
+	is.Equal(res.Query("errors.#.path.0"), []interface{}{"x"})
+	is.Equal(res.Query("extensions.cost.requested"), float64(3))
+	is.Equal(res.Query(`data.a\.b`), float64(1))
+	is.Equal(res.Get(`a\.b`), float64(1))
+	is.Equal(res.Query("errors.x"), nil)
+}
+
+func TestWithKeepRawBody(t *testing.T) {
+	is := is.New(t)
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	io.WriteString(gz, `{"data":{"value":"some data"}}`)
+	is.NoErr(gz.Close())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	res, err := NewClient(srv.URL, WithAcceptEncoding("gzip")).Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(res.Bytes(), nil) // not kept by default
+
+	res, err = NewClient(srv.URL, WithAcceptEncoding("gzip"), WithKeepRawBody()).Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(res.Bytes(), compressed.Bytes())
+	is.Equal(res.GetString("value"), "some data")
+}
+
+func TestResponseHTTP(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			http.Redirect(w, r, "/graphql", http.StatusPermanentRedirect)
+			return
+		}
+		w.Header().Set("Trailer", "X-Checksum")
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+		w.Header().Set("X-Checksum", "abc")
+	}))
+	defer srv.Close()
+
+	res, err := NewClient(srv.URL+"/old", WithHTTPClient(srv.Client())).Run(context.Background(), NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.True(res.TLS != nil)
+	is.Equal(res.ProtoMajor, 1)
+	is.Equal(res.Trailer.Get("X-Checksum"), "abc")
+	b, err := io.ReadAll(res.Body)
+	is.NoErr(err) // the consumed body reads as empty
+	is.Equal(len(b), 0)
+
+	redirects := res.Redirects()
+	is.Equal(len(redirects), 1)
+	is.Equal(redirects[0].StatusCode, http.StatusPermanentRedirect)
+	is.Equal(res.Request.URL.Path, "/graphql")
+}
+
+func TestResponsePersistedQuery(t *testing.T) {
+	is := is.New(t)
+
+	body := `{"data":{"name":"some data"}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithPersistedQueries(), WithKeepRawBody())
+	res, err := client.Run(context.Background(), NewRequest("query { name }"), nil)
+	is.NoErr(err)
+	is.Equal(res.GetString("name"), "some data")
+	is.Equal(string(res.Bytes()), body)
+}
+
+func TestResponseMetadata(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/graphql" {
+			http.Redirect(w, r, "/graphql?from=old", http.StatusTemporaryRedirect)
+			return
+		}
+		w.Header().Set("X-Cache", "MISS, HIT")
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL+"/old", WithRetry(3, func(int) time.Duration { return time.Millisecond }))
+	res, err := client.Run(context.Background(), NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(res.Attempts, 2)
+	is.True(res.Duration >= time.Millisecond) // includes the wait between attempts
+	is.Equal(res.Endpoint, srv.URL+"/graphql")
+	is.True(res.Cached)
+}
+
+func TestResponseEndpointCredentials(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	endpoint := strings.Replace(srv.URL, "http://", "http://user:secret@", 1)
+	res, err := NewClient(endpoint+"/graphql?key=abc").Run(context.Background(), NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(res.Endpoint, srv.URL+"/graphql")
+}
+
+func TestResponseCached(t *testing.T) {
+	is := is.New(t)
+
+	for _, test := range []struct {
+		header http.Header
+		cached bool
+	}{
+		{http.Header{}, false},
+		{http.Header{"Age": {"0"}}, true},
+		{http.Header{"X-Cache": {"Miss from cloudfront"}}, false},
+		{http.Header{"X-Cache": {"Hit from cloudfront"}}, true},
+		{http.Header{"Cf-Cache-Status": {"HIT"}}, true},
+		{http.Header{"Cf-Cache-Status": {"DYNAMIC"}}, false},
+		{http.Header{"Cache-Status": {"ExampleCache; fwd=uri-miss"}}, false},
+		{http.Header{"Cache-Status": {"Origin; fwd=miss, ExampleCache; hit; ttl=30"}}, true},
+	} {
+		is.Equal(fromCache(test.header), test.cached) // test.header
+	}
+}
+
+func TestResponseMetadataRunner(t *testing.T) {
+	is := is.New(t)
+
+	client := NewClient("http://api.test/graphql", WithRunner(RunnerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		return NewResponse([]byte(`{"data":{}}`)), nil
+	})))
+	res, err := client.Run(context.Background(), NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(res.Attempts, 1)
+	is.Equal(res.Endpoint, "") // the runner didn't say
+	is.True(!res.Cached)
+}
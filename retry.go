@@ -0,0 +1,224 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrDeadlineWouldBeExceeded is reported, wrapped around the error from
+// the last attempt, when a request isn't retried because the retry
+// couldn't finish before the context deadline.
+var ErrDeadlineWouldBeExceeded = errors.New("graphql: retry would exceed the context deadline")
+
+// deadlineError is returned when a retry is skipped for lack of time.
+type deadlineError struct {
+	err error
+}
+
+func (e *deadlineError) Error() string {
+	return ErrDeadlineWouldBeExceeded.Error() + ": " + e.err.Error()
+}
+
+func (e *deadlineError) Is(target error) bool {
+	return target == ErrDeadlineWouldBeExceeded
+}
+
+func (e *deadlineError) Unwrap() error {
+	return e.err
+}
+
+// WithRetry makes the client attempt each request up to maxAttempts
+// times, waiting backoff(attempt) after a failed attempt before trying
+// again. A nil backoff waits 100ms after the first attempt, doubling
+// each time, up to 10s.
+//
+// Requests are retried when the server couldn't be reached or answered
+// with a 429 or 5xx status, with GraphQL errors WithRetryableErrors
+// accepts, or with a ThrottledError, in which case the retry waits at
+// least as long as its RetryAfter says. Only queries are retried unless
+// a request is marked safe to repeat with Request.Idempotent, or has an
+// idempotency key set with Request.IdempotencyKey, since repeating a
+// mutation the server had in fact applied could apply it twice.
+// Subscriptions are never retried, and nor are requests with files
+// unless every file's reader is an io.Seeker, which is rewound before
+// each retry. JSON bodies are encoded once and sent again as they are
+// by each retry. A retry is skipped, and the error wrapped with
+// ErrDeadlineWouldBeExceeded, when the context deadline would pass
+// before the retry could finish, judging by how long the last attempt
+// took.
+func WithRetry(maxAttempts int, backoff func(attempt int) time.Duration) ClientOption {
+	return func(client *Client) {
+		client.maxAttempts = maxAttempts
+		client.backoff = backoff
+	}
+}
+
+// runWithRetries runs the request, retrying it according to the
+// client's retry policy.
+func (c *Client) runWithRetries(ctx context.Context, req *Request, gr *graphResponse) (*http.Response, error) {
+	// every attempt must carry the same idempotency key
+	req = c.withIdempotencyKey(req)
+	maxAttempts := c.maxAttempts
+	rewind, rewindable := req.fileRewinder()
+	if !req.safeToRetry() || !rewindable {
+		maxAttempts = 1
+	}
+	backoff := c.backoff
+	if backoff == nil {
+		backoff = exponentialBackoff
+	}
+	defer req.abortUploadsWhenDone(ctx)()
+	gr.bodies = &requestBodies{}
+	defer func() {
+		gr.bodies.release()
+		gr.bodies = nil
+	}()
+	for attempt := 1; ; attempt++ {
+		gr.attempts = attempt
+		start := time.Now()
+		res, err := c.runAttempt(ctx, req, gr)
+		if err == nil || attempt >= maxAttempts || !c.isRetryable(ctx, res, err) {
+			return res, err
+		}
+		delay := backoff(attempt)
+		var throttledErr *ThrottledError
+		if errors.As(err, &throttledErr) && throttledErr.RetryAfter > delay {
+			delay = throttledErr.RetryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay+time.Since(start) {
+			return res, &deadlineError{err: err}
+		}
+		c.logf("attempt %d failed, retrying: %s", attempt, err)
+		c.stats.retries.Add(1)
+		c.events.RetryScheduled(RetryScheduledEvent{Request: req, Attempt: attempt, Err: err, Delay: delay})
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, ctx.Err()
+		case <-timer.C:
+		}
+		if err := rewind(); err != nil {
+			return res, errors.Wrap(err, "rewind files")
+		}
+	}
+}
+
+// Idempotent marks the request as safe to send more than once, allowing
+// the client to retry it even if it is a mutation.
+func (req *Request) Idempotent() {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.idempotent = true
+}
+
+// fileRewinder returns a function that rewinds the request's file
+// readers to where they are now, so that a retry sends the files again
+// rather than whatever is left of them. It returns false if any reader
+// can't be rewound, as the request then can't be retried.
+func (req *Request) fileRewinder() (func() error, bool) {
+	req.mu.Lock()
+	files := append([]File(nil), req.files...)
+	req.mu.Unlock()
+	seekers := make([]io.Seeker, len(files))
+	offsets := make([]int64, len(files))
+	for i, f := range files {
+		seeker, ok := f.R.(io.Seeker)
+		if !ok {
+			return nil, false
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, false
+		}
+		seekers[i], offsets[i] = seeker, offset
+	}
+	return func() error {
+		for i, seeker := range seekers {
+			if _, err := seeker.Seek(offsets[i], io.SeekStart); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, true
+}
+
+// safeToRetry reports whether repeating the request can't cause harm.
+func (req *Request) safeToRetry() bool {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	if req.idempotent || req.idempotencyKey != "" && !req.generatedKey {
+		return true
+	}
+	return operationType(req.q) == "query"
+}
+
+// WithRetryableErrors makes the client also retry requests that failed
+// with a GraphQL error for which retryable returns true, for servers and
+// gateways that report transient failures in the response body rather
+// than with the HTTP status. It has no effect without WithRetry.
+func WithRetryableErrors(retryable func(err GraphQLError) bool) ClientOption {
+	return func(client *Client) {
+		client.retryableErrors = retryable
+	}
+}
+
+// WithRetryableErrorCodes is WithRetryableErrors for errors whose
+// extensions code is one of codes, such as THROTTLED or
+// SERVICE_UNAVAILABLE.
+func WithRetryableErrorCodes(codes ...string) ClientOption {
+	return WithRetryableErrors(func(err GraphQLError) bool {
+		code, _ := err.Extensions["code"].(string)
+		for _, c := range codes {
+			if code == c {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// isRetryable reports whether an attempt that failed with err might
+// succeed if it was tried again.
+func (c *Client) isRetryable(ctx context.Context, res *http.Response, err error) bool {
+	if ctx.Err() != nil || err == ErrClientClosed {
+		return false
+	}
+	if res == nil {
+		// only failures to reach the server are worth trying again;
+		// requests that couldn't be built or read fail the same way
+		// every time
+		var transportErr *TransportError
+		return errors.As(err, &transportErr)
+	}
+	var throttledErr *ThrottledError
+	if errors.As(err, &throttledErr) {
+		return true
+	}
+	var responseErr *ResponseError
+	if c.retryableErrors != nil && errors.As(err, &responseErr) {
+		for _, gqlErr := range responseErr.Errors {
+			if c.retryableErrors(gqlErr) {
+				return true
+			}
+		}
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// exponentialBackoff waits 100ms after the first attempt, doubling each
+// time up to a maximum of 10s.
+func exponentialBackoff(attempt int) time.Duration {
+	d := 100 * time.Millisecond
+	for i := 1; i < attempt && d < 10*time.Second; i++ {
+		d *= 2
+	}
+	if d > 10*time.Second {
+		d = 10 * time.Second
+	}
+	return d
+}
@@ -0,0 +1,236 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRetry(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithRetry(3, func(int) time.Duration { return time.Millisecond }))
+	var resp struct {
+		Value string
+	}
+	_, err := client.Run(ctx, NewRequest("query {}"), &resp)
+	is.NoErr(err)
+	is.Equal(calls, 3)
+	is.Equal(resp.Value, "some data")
+	is.Equal(client.Stats().Retries, int64(2))
+}
+
+func TestRetryNotForGraphQLErrors(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"errors":[{"message":"bad query"}]}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithRetry(3, nil))
+	_, err := client.Run(ctx, NewRequest("query {}"), nil)
+	is.Equal(err.Error(), "graphql: bad query")
+	is.Equal(calls, 1)
+}
+
+func TestRetryDeadlineBudget(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithRetry(3, func(int) time.Duration { return time.Second }))
+	start := time.Now()
+	_, err := client.Run(ctx, NewRequest("query {}"), nil)
+	is.True(errors.Is(err, ErrDeadlineWouldBeExceeded))
+	is.True(time.Since(start) < 100*time.Millisecond) // didn't wait for the deadline
+	is.Equal(calls, 1)
+}
+
+func TestRetryOnlySafeOperations(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithRetry(2, func(int) time.Duration { return time.Millisecond }))
+	for _, test := range []struct {
+		req   *Request
+		calls int
+	}{
+		{NewRequest(`{ viewer { id } }`), 2},
+		{NewRequest(`query Viewer { viewer { id } }`), 2},
+		{NewRequest(`mutation { charge }`), 1},
+		{NewRequest(`subscription { events }`), 1},
+	} {
+		calls = 0
+		client.Run(ctx, test.req, nil)
+		is.Equal(calls, test.calls) // test.req.Query()
+	}
+
+	calls = 0
+	req := NewRequest(`mutation { setName(name: "x") }`)
+	req.Idempotent()
+	client.Run(ctx, req, nil)
+	is.Equal(calls, 2)
+}
+
+func TestRetryNotForGeneratedIdempotencyKeys(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithAutoIdempotencyKeys(), WithRetry(2, func(int) time.Duration { return time.Millisecond }))
+	client.Run(ctx, NewRequest(`mutation { charge }`), nil)
+	is.Equal(calls, 1) // a generated key alone doesn't make it safe
+
+	calls = 0
+	req := NewRequest(`mutation { charge }`)
+	req.IdempotencyKey("abc")
+	client.Run(ctx, req, nil)
+	is.Equal(calls, 2)
+}
+
+func TestRetryableErrorCodes(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			io.WriteString(w, `{"errors":[{"message":"slow down","extensions":{"code":"THROTTLED"}}]}`)
+		case 2:
+			io.WriteString(w, `{"errors":[{"message":"try later","extensions":{"code":"SERVICE_UNAVAILABLE"}}]}`)
+		default:
+			io.WriteString(w, `{"errors":[{"message":"bad input","extensions":{"code":"BAD_USER_INPUT"}}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL,
+		WithRetry(5, func(int) time.Duration { return time.Millisecond }),
+		WithRetryableErrorCodes("THROTTLED", "SERVICE_UNAVAILABLE"),
+	)
+	_, err := client.Run(context.Background(), NewRequest("query {}"), nil)
+	is.Equal(err.Error(), "graphql: bad input")
+	is.Equal(calls, 3) // stopped at the first error that isn't retryable
+}
+
+func TestRetryFiles(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	var uploads []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		file, _, err := r.FormFile("file")
+		is.NoErr(err)
+		b, err := io.ReadAll(file)
+		is.NoErr(err)
+		uploads = append(uploads, string(b))
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithRetry(2, func(int) time.Duration { return time.Millisecond }))
+
+	// a reader that can be rewound is sent again in full
+	req := NewRequest(`mutation { upload }`)
+	req.Idempotent()
+	req.File("file", "a.txt", strings.NewReader("contents"))
+	_, err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(uploads, []string{"contents", "contents"})
+
+	// one that can't isn't retried
+	calls, uploads = 0, nil
+	req = NewRequest(`mutation { upload }`)
+	req.Idempotent()
+	req.File("file", "a.txt", io.MultiReader(strings.NewReader("contents")))
+	_, err = client.Run(ctx, req, nil)
+	is.True(err != nil)
+	is.Equal(uploads, []string{"contents"})
+}
+
+func TestRetryOnlyTransportFailures(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL,
+		WithTransport(TransportGET),
+		WithRetry(3, func(int) time.Duration { return time.Millisecond }),
+	)
+	req := NewRequest(`query { file }`)
+	req.File("file", "a.txt", strings.NewReader("contents"))
+	_, err := client.Run(ctx, req, nil)
+	is.True(err != nil)
+	is.Equal(calls, 0)
+	is.Equal(client.Stats().Retries, int64(0))
+
+	// a server that can't be reached is tried again
+	srv.Close()
+	_, err = client.Run(ctx, NewRequest(`query { ok }`), nil)
+	var transportErr *TransportError
+	is.True(errors.As(err, &transportErr))
+	is.Equal(client.Stats().Retries, int64(2))
+}
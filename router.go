@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"context"
+	"strings"
+)
+
+// Router sends each request to one of several clients according to its
+// operation, so callers can use a single facade while, say, mutations go
+// to a primary and queries to a read replica:
+//
+//	router := graphql.NewRouter(replica,
+//	    graphql.Route{Match: graphql.MatchOperationType("mutation"), Client: primary},
+//	    graphql.Route{Match: graphql.MatchOperationPrefix("Admin"), Client: admin},
+//	)
+//	res, err := router.Run(ctx, req, &resp)
+type Router struct {
+	routes   []Route
+	fallback *Client
+}
+
+// Route sends operations that Match to Client.
+type Route struct {
+	Match  OperationMatcher
+	Client *Client
+}
+
+// OperationMatcher reports whether an operation, given by its type
+// (query, mutation or subscription) and name, matches a Route. The name
+// is empty for anonymous operations.
+type OperationMatcher func(typ, name string) bool
+
+// MatchOperationType matches operations of the type typ.
+func MatchOperationType(typ string) OperationMatcher {
+	return func(t, _ string) bool {
+		return t == typ
+	}
+}
+
+// MatchOperationPrefix matches operations whose name starts with prefix.
+func MatchOperationPrefix(prefix string) OperationMatcher {
+	return func(_, name string) bool {
+		return name != "" && strings.HasPrefix(name, prefix)
+	}
+}
+
+// NewRouter makes a Router that tries routes in order and sends each
+// request to the client of the first that matches, or to fallback if
+// none do.
+func NewRouter(fallback *Client, routes ...Route) *Router {
+	return &Router{routes: routes, fallback: fallback}
+}
+
+// Client returns the client req would be sent to. Requests that can't be
+// parsed go to the fallback, which reports the error. If the document
+// contains more than one operation the first is used.
+func (r *Router) Client(req *Request) *Client {
+	doc, err := parseDocument(req.Query())
+	if err != nil || len(doc.operations) == 0 {
+		return r.fallback
+	}
+	op := doc.operations[0]
+	for _, route := range r.routes {
+		if route.Match(op.typ, op.name) {
+			return route.Client
+		}
+	}
+	return r.fallback
+}
+
+// Run runs req on the client it routes to. See Client.Run.
+func (r *Router) Run(ctx context.Context, req *Request, resp interface{}) (*Response, error) {
+	return r.Client(req).Run(ctx, req, resp)
+}
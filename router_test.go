@@ -0,0 +1,45 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRouter(t *testing.T) {
+	is := is.New(t)
+
+	var hits []string
+	endpoint := func(name string) *Client {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits = append(hits, name)
+			io.WriteString(w, `{"data":{}}`)
+		}))
+		t.Cleanup(srv.Close)
+		return NewClient(srv.URL)
+	}
+	replica, primary, admin := endpoint("replica"), endpoint("primary"), endpoint("admin")
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	router := NewRouter(replica,
+		Route{Match: MatchOperationPrefix("Admin"), Client: admin},
+		Route{Match: MatchOperationType("mutation"), Client: primary},
+	)
+	for _, q := range []string{
+		"{ a }",
+		"query Items { a }",
+		"mutation Save { a }",
+		"mutation AdminReset { a }",
+		"query AdminUsers { a }",
+		"not graphql",
+	} {
+		router.Run(ctx, NewRequest(q), nil)
+	}
+	is.Equal(hits, []string{"replica", "replica", "primary", "admin", "admin", "replica"})
+}
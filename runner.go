@@ -0,0 +1,156 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Runner executes requests, returning the server's response without
+// decoding its data. The client's own HTTP transports, JSON, multipart
+// and GET, are what a Client runs requests with by default;
+// WithRunner replaces them with another Runner, such as one speaking
+// WebSocket or SSE, or one that answers requests without a network at
+// all.
+//
+// A Runner may return GraphQL errors in the body of the Response alone,
+// or also as a ResponseError as Client.Execute does; the client decodes
+// the body either way. Any other error fails the attempt.
+type Runner interface {
+	Execute(ctx context.Context, req *Request) (*Response, error)
+}
+
+// RunnerFunc is a function that implements Runner.
+type RunnerFunc func(ctx context.Context, req *Request) (*Response, error)
+
+// Execute calls f(ctx, req).
+func (f RunnerFunc) Execute(ctx context.Context, req *Request) (*Response, error) {
+	return f(ctx, req)
+}
+
+// WithRunner makes the client execute requests with r in place of its
+// own HTTP transports. Everything the client does around each attempt
+// still applies: the allowlist, variable conversion, timeouts, retries,
+// events and decoding the data into the response object. Options that
+// only concern how requests are sent over HTTP, such as WithTransport
+// and WithPersistedQueries, are left to r.
+//
+// r is passed a copy of each request with its variables already
+// converted, and is called once for each attempt.
+func WithRunner(r Runner) ClientOption {
+	return func(client *Client) {
+		client.runner = r
+	}
+}
+
+// Execute runs the request as Run does, without decoding its data, so
+// that a Client is itself a Runner: one client can run its requests
+// through another, or a custom Runner can wrap a client.
+func (c *Client) Execute(ctx context.Context, req *Request) (*Response, error) {
+	return c.Run(ctx, req, nil)
+}
+
+// NewResponse makes a Response with the given body, for Runners that
+// don't receive their responses over HTTP. It has a 200 status and no
+// headers.
+func NewResponse(body []byte) *Response {
+	return &Response{
+		Response: &http.Response{
+			Status:     "200 OK",
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+		},
+		body: body,
+	}
+}
+
+// Middleware wraps a Runner to act on the requests it runs and the
+// responses it returns, such as to sign requests or transform
+// responses:
+//
+//	func sign(next graphql.Runner) graphql.Runner {
+//	    return graphql.RunnerFunc(func(ctx context.Context, req *graphql.Request) (*graphql.Response, error) {
+//	        req.Header.Set("X-Signature", signature(req))
+//	        return next.Execute(ctx, req)
+//	    })
+//	}
+//
+// Middleware is called for each attempt, with a copy of the request
+// taken for the attempt that it may change freely. The Runner it wraps
+// returns the response without decoding its data, which the client
+// decodes from the response the outermost middleware returns.
+type Middleware func(next Runner) Runner
+
+// WithMiddleware adds middleware that every request the client runs
+// passes through, the first given outermost.
+func WithMiddleware(mw ...Middleware) ClientOption {
+	return func(client *Client) {
+		client.middleware = append(client.middleware[:len(client.middleware):len(client.middleware)], mw...)
+	}
+}
+
+// Use adds middleware for this request alone, such as an extra signing
+// step for a sensitive mutation. It runs inside the client's middleware,
+// after it on the way out and before it on the way back, the first
+// given outermost.
+func (req *Request) Use(mw ...Middleware) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.middleware = append(req.middleware[:len(req.middleware):len(req.middleware)], mw...)
+}
+
+// runWithMiddleware sends req through the client's and the request's
+// middleware, decoding the response into gr.
+func (c *Client) runWithMiddleware(ctx context.Context, req *Request, gr *graphResponse) (*http.Response, error) {
+	var runner Runner = RunnerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		// the data is left for decoding once the middleware is done
+		inner := &graphResponse{bodies: gr.bodies}
+		res, err := c.dispatch(ctx, req, inner)
+		if res == nil {
+			return nil, err
+		}
+		return &Response{Response: res, body: inner.body, rawBody: inner.rawBody, useNumber: c.useNumber, costParser: c.costParser}, err
+	})
+	for i := len(req.middleware) - 1; i >= 0; i-- {
+		runner = req.middleware[i](runner)
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		runner = c.middleware[i](runner)
+	}
+	resp, err := runner.Execute(ctx, req)
+	return c.decodeRunnerResponse(resp, err, gr)
+}
+
+// runWithRunner executes the request with the client's Runner and decodes
+// the response into gr.
+func (c *Client) runWithRunner(ctx context.Context, req *Request, gr *graphResponse) (*http.Response, error) {
+	resp, err := c.runner.Execute(ctx, req)
+	if resp != nil {
+		c.logf("<< %s", resp.body)
+	}
+	return c.decodeRunnerResponse(resp, err, gr)
+}
+
+// decodeRunnerResponse decodes resp, returned by a Runner with err, into
+// gr.
+func (c *Client) decodeRunnerResponse(resp *Response, err error, gr *graphResponse) (*http.Response, error) {
+	if resp == nil {
+		if err == nil {
+			err = errors.New("graphql: runner returned no response")
+		}
+		return nil, err
+	}
+	res := resp.Response
+	if res == nil {
+		res = NewResponse(nil).Response
+	}
+	gr.rawBody = resp.rawBody
+	var responseErr *ResponseError
+	if err != nil && !errors.As(err, &responseErr) {
+		gr.body = resp.body
+		return res, err
+	}
+	return c.decodeBody(res, resp.body, gr)
+}
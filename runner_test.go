@@ -0,0 +1,79 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithRunner(t *testing.T) {
+	is := is.New(t)
+
+	var got *Request
+	runner := RunnerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		got = req
+		return NewResponse([]byte(`{"data":{"item":"some data"}}`)), nil
+	})
+	client := NewClient("unused", WithRunner(runner))
+	req := NewRequest("query ($at: Time) { item(at: $at) }")
+	req.Var("at", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	var resp struct {
+		Item string
+	}
+	res, err := client.Run(context.Background(), req, &resp)
+	is.NoErr(err)
+	is.Equal(resp.Item, "some data")
+	is.Equal(res.StatusCode, http.StatusOK)
+	is.Equal(res.GetString("item"), "some data")
+	is.Equal(got.Query(), req.Query())
+	is.True(got != req) // a copy taken for the attempt
+}
+
+func TestWithRunnerErrors(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	runner := RunnerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		calls++
+		return NewResponse([]byte(`{"errors":[{"message":"busy","extensions":{"code":"BUSY"}}]}`)), nil
+	})
+	client := NewClient("unused", WithRunner(runner),
+		WithRetry(2, func(int) time.Duration { return 0 }), WithRetryableErrorCodes("BUSY"))
+	_, err := client.Run(context.Background(), NewRequest("query { item }"), nil)
+	var responseErr *ResponseError
+	is.True(errors.As(err, &responseErr))
+	is.Equal(responseErr.Errors[0].Message, "busy")
+	is.Equal(calls, 2)
+
+	failed := errors.New("no connection")
+	client = NewClient("unused", WithRunner(RunnerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, failed
+	})))
+	res, err := client.Run(context.Background(), NewRequest("query { item }"), nil)
+	is.Equal(err, failed)
+	is.Equal(res, nil)
+}
+
+func TestClientAsRunner(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"item":"some data"},"errors":[{"message":"partial"}]}`)
+	}))
+	defer srv.Close()
+
+	inner := NewClient(srv.URL)
+	outer := NewClient("unused", WithRunner(inner))
+	var resp struct {
+		Item string
+	}
+	_, err := outer.Run(context.Background(), NewRequest("query { item }"), &resp)
+	is.Equal(err.Error(), "graphql: partial")
+	is.Equal(resp.Item, "some data")
+}
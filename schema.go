@@ -0,0 +1,349 @@
+package graphql
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Schema is a GraphQL schema as described by the server's introspection
+// query. It is decoded from, and encodes back to, the __schema field of
+// the introspection response.
+type Schema struct {
+	QueryType        *TypeRef          `json:"queryType"`
+	MutationType     *TypeRef          `json:"mutationType"`
+	SubscriptionType *TypeRef          `json:"subscriptionType"`
+	Types            []SchemaType      `json:"types"`
+	Directives       []SchemaDirective `json:"directives"`
+}
+
+// SchemaType is a named type in a Schema.
+type SchemaType struct {
+	// Kind is one of SCALAR, OBJECT, INTERFACE, UNION, ENUM or
+	// INPUT_OBJECT.
+	Kind          string             `json:"kind"`
+	Name          string             `json:"name"`
+	Description   string             `json:"description,omitempty"`
+	Fields        []SchemaField      `json:"fields"`
+	InputFields   []SchemaInputValue `json:"inputFields"`
+	Interfaces    []TypeRef          `json:"interfaces"`
+	EnumValues    []SchemaEnumValue  `json:"enumValues"`
+	PossibleTypes []TypeRef          `json:"possibleTypes"`
+}
+
+// SchemaField is a field of an object or interface type.
+type SchemaField struct {
+	Name              string             `json:"name"`
+	Description       string             `json:"description,omitempty"`
+	Args              []SchemaInputValue `json:"args"`
+	Type              TypeRef            `json:"type"`
+	IsDeprecated      bool               `json:"isDeprecated"`
+	DeprecationReason string             `json:"deprecationReason,omitempty"`
+}
+
+// SchemaInputValue is an argument, or a field of an input object type.
+type SchemaInputValue struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Type        TypeRef `json:"type"`
+	// DefaultValue is the default as a GraphQL literal, or nil if there
+	// is none.
+	DefaultValue *string `json:"defaultValue"`
+}
+
+// SchemaEnumValue is one of the values of an enum type.
+type SchemaEnumValue struct {
+	Name              string `json:"name"`
+	Description       string `json:"description,omitempty"`
+	IsDeprecated      bool   `json:"isDeprecated"`
+	DeprecationReason string `json:"deprecationReason,omitempty"`
+}
+
+// SchemaDirective is a directive supported by a Schema.
+type SchemaDirective struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Locations   []string           `json:"locations"`
+	Args        []SchemaInputValue `json:"args"`
+}
+
+// TypeRef refers to a type. NON_NULL and LIST references wrap the type
+// in OfType; any other kind names a type in the Schema.
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name,omitempty"`
+	OfType *TypeRef `json:"ofType,omitempty"`
+}
+
+// String renders the reference as it's written in GraphQL, for example
+// [String!]!.
+func (t TypeRef) String() string {
+	switch {
+	case t.Kind == "NON_NULL" && t.OfType != nil:
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST" && t.OfType != nil:
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// NamedType returns the name of the type the reference refers to once
+// any NON_NULL and LIST wrappers are removed.
+func (t TypeRef) NamedType() string {
+	for t.OfType != nil {
+		t = *t.OfType
+	}
+	return t.Name
+}
+
+// Type returns the named type, or nil if the schema has no such type.
+func (s *Schema) Type(name string) *SchemaType {
+	for i := range s.Types {
+		if s.Types[i].Name == name {
+			return &s.Types[i]
+		}
+	}
+	return nil
+}
+
+// Field returns the named field of the type, or nil if it has no such
+// field.
+func (t *SchemaType) Field(name string) *SchemaField {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+// introspectionQuery asks for everything Schema describes.
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}`
+
+// Introspect fetches the server's schema with the introspection query.
+func (c *Client) Introspect(ctx context.Context) (*Schema, error) {
+	var resp struct {
+		Schema *Schema `json:"__schema"`
+	}
+	if _, err := c.Run(ctx, NewRequest(introspectionQuery), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Schema == nil {
+		return nil, errors.New("graphql: introspection returned no schema")
+	}
+	return resp.Schema, nil
+}
+
+// builtinScalars are the scalars every schema has, which SDL leaves out.
+var builtinScalars = map[string]bool{
+	"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true,
+}
+
+// builtinDirectives are the directives every schema has, which SDL
+// leaves out.
+var builtinDirectives = map[string]bool{
+	"skip": true, "include": true, "deprecated": true, "specifiedBy": true, "oneOf": true,
+}
+
+// SDL renders the schema in the GraphQL schema definition language.
+// Built in scalars, directives and introspection types are left out, and
+// types are sorted by name so that the output of equal schemas is the
+// same.
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	if s.usesCustomRootNames() {
+		b.WriteString("schema {\n")
+		for _, root := range []struct {
+			op  string
+			ref *TypeRef
+		}{{"query", s.QueryType}, {"mutation", s.MutationType}, {"subscription", s.SubscriptionType}} {
+			if root.ref != nil {
+				b.WriteString("  " + root.op + ": " + root.ref.Name + "\n")
+			}
+		}
+		b.WriteString("}\n\n")
+	}
+	directives := append([]SchemaDirective(nil), s.Directives...)
+	sort.Slice(directives, func(i, j int) bool { return directives[i].Name < directives[j].Name })
+	for _, d := range directives {
+		if builtinDirectives[d.Name] {
+			continue
+		}
+		writeDescription(&b, "", d.Description)
+		b.WriteString("directive @" + d.Name + sdlArgs(d.Args) + " on " + strings.Join(d.Locations, " | ") + "\n\n")
+	}
+	types := append([]SchemaType(nil), s.Types...)
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") || (t.Kind == "SCALAR" && builtinScalars[t.Name]) {
+			continue
+		}
+		writeDescription(&b, "", t.Description)
+		switch t.Kind {
+		case "SCALAR":
+			b.WriteString("scalar " + t.Name + "\n\n")
+			continue
+		case "UNION":
+			members := make([]string, len(t.PossibleTypes))
+			for i, member := range t.PossibleTypes {
+				members[i] = member.Name
+			}
+			b.WriteString("union " + t.Name + " = " + strings.Join(members, " | ") + "\n\n")
+			continue
+		case "OBJECT":
+			b.WriteString("type " + t.Name)
+		case "INTERFACE":
+			b.WriteString("interface " + t.Name)
+		case "ENUM":
+			b.WriteString("enum " + t.Name)
+		case "INPUT_OBJECT":
+			b.WriteString("input " + t.Name)
+		}
+		if len(t.Interfaces) > 0 {
+			names := make([]string, len(t.Interfaces))
+			for i, iface := range t.Interfaces {
+				names[i] = iface.Name
+			}
+			b.WriteString(" implements " + strings.Join(names, " & "))
+		}
+		b.WriteString(" {\n")
+		for _, f := range t.Fields {
+			writeDescription(&b, "  ", f.Description)
+			b.WriteString("  " + f.Name + sdlArgs(f.Args) + ": " + f.Type.String() + sdlDeprecated(f.IsDeprecated, f.DeprecationReason) + "\n")
+		}
+		for _, f := range t.InputFields {
+			writeDescription(&b, "  ", f.Description)
+			b.WriteString("  " + sdlInputValue(f) + "\n")
+		}
+		for _, v := range t.EnumValues {
+			writeDescription(&b, "  ", v.Description)
+			b.WriteString("  " + v.Name + sdlDeprecated(v.IsDeprecated, v.DeprecationReason) + "\n")
+		}
+		b.WriteString("}\n\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// usesCustomRootNames reports whether the root operation types are named
+// other than Query, Mutation and Subscription, in which case SDL needs a
+// schema definition.
+func (s *Schema) usesCustomRootNames() bool {
+	return (s.QueryType != nil && s.QueryType.Name != "Query") ||
+		(s.MutationType != nil && s.MutationType.Name != "Mutation") ||
+		(s.SubscriptionType != nil && s.SubscriptionType.Name != "Subscription")
+}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	b.WriteString(indent + `"""` + "\n")
+	for _, line := range strings.Split(strings.ReplaceAll(description, `"""`, `\"""`), "\n") {
+		b.WriteString(indent + line + "\n")
+	}
+	b.WriteString(indent + `"""` + "\n")
+}
+
+func sdlArgs(args []SchemaInputValue) string {
+	if len(args) == 0 {
+		return ""
+	}
+	rendered := make([]string, len(args))
+	for i, arg := range args {
+		rendered[i] = sdlInputValue(arg)
+	}
+	return "(" + strings.Join(rendered, ", ") + ")"
+}
+
+func sdlInputValue(v SchemaInputValue) string {
+	s := v.Name + ": " + v.Type.String()
+	if v.DefaultValue != nil {
+		s += " = " + *v.DefaultValue
+	}
+	return s
+}
+
+func sdlDeprecated(deprecated bool, reason string) string {
+	switch {
+	case !deprecated:
+		return ""
+	case reason == "" || reason == "No longer supported":
+		return " @deprecated"
+	}
+	return " @deprecated(reason: " + quoteString(reason) + ")"
+}
+
+// quoteString renders s as a GraphQL string literal.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
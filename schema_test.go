@@ -0,0 +1,144 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func named(kind, name string) TypeRef {
+	return TypeRef{Kind: kind, Name: name}
+}
+
+func nonNull(t TypeRef) TypeRef {
+	return TypeRef{Kind: "NON_NULL", OfType: &t}
+}
+
+func list(t TypeRef) TypeRef {
+	return TypeRef{Kind: "LIST", OfType: &t}
+}
+
+// testSchema returns a small schema for the tests to work with.
+func testSchema() *Schema {
+	def := "10"
+	return &Schema{
+		QueryType:    &TypeRef{Name: "Query"},
+		MutationType: &TypeRef{Name: "Mutation"},
+		Types: []SchemaType{
+			{Kind: "SCALAR", Name: "String"},
+			{Kind: "SCALAR", Name: "ID"},
+			{Kind: "SCALAR", Name: "Int"},
+			{Kind: "SCALAR", Name: "Time", Description: "An RFC 3339 time."},
+			{Kind: "OBJECT", Name: "Query", Fields: []SchemaField{
+				{Name: "item", Args: []SchemaInputValue{{Name: "id", Type: nonNull(named("SCALAR", "ID"))}}, Type: named("OBJECT", "Item")},
+				{Name: "items", Args: []SchemaInputValue{{Name: "first", Type: named("SCALAR", "Int"), DefaultValue: &def}}, Type: nonNull(list(nonNull(named("OBJECT", "Item"))))},
+				{Name: "search", Args: []SchemaInputValue{{Name: "text", Type: nonNull(named("SCALAR", "String"))}}, Type: list(named("UNION", "SearchResult"))},
+			}},
+			{Kind: "OBJECT", Name: "Mutation", Fields: []SchemaField{
+				{Name: "updateItem", Args: []SchemaInputValue{{Name: "input", Type: nonNull(named("INPUT_OBJECT", "ItemInput"))}}, Type: named("OBJECT", "Item")},
+			}},
+			{Kind: "INTERFACE", Name: "Node", Fields: []SchemaField{
+				{Name: "id", Type: nonNull(named("SCALAR", "ID"))},
+			}, PossibleTypes: []TypeRef{named("OBJECT", "Item")}},
+			{Kind: "OBJECT", Name: "Item", Description: "Something for sale.", Interfaces: []TypeRef{named("INTERFACE", "Node")}, Fields: []SchemaField{
+				{Name: "id", Type: nonNull(named("SCALAR", "ID"))},
+				{Name: "name", Type: named("SCALAR", "String")},
+				{Name: "status", Type: named("ENUM", "Status")},
+				{Name: "updated", Type: named("SCALAR", "Time"), IsDeprecated: true, DeprecationReason: "Use updatedAt."},
+			}},
+			{Kind: "OBJECT", Name: "User", Fields: []SchemaField{
+				{Name: "login", Type: named("SCALAR", "String")},
+			}},
+			{Kind: "UNION", Name: "SearchResult", PossibleTypes: []TypeRef{named("OBJECT", "Item"), named("OBJECT", "User")}},
+			{Kind: "ENUM", Name: "Status", EnumValues: []SchemaEnumValue{{Name: "ACTIVE"}, {Name: "SOLD"}}},
+			{Kind: "INPUT_OBJECT", Name: "ItemInput", InputFields: []SchemaInputValue{
+				{Name: "id", Type: nonNull(named("SCALAR", "ID"))},
+				{Name: "name", Type: named("SCALAR", "String")},
+			}},
+			{Kind: "OBJECT", Name: "__Type"},
+		},
+		Directives: []SchemaDirective{
+			{Name: "include", Locations: []string{"FIELD"}},
+			{Name: "cached", Args: []SchemaInputValue{{Name: "ttl", Type: named("SCALAR", "Int")}}, Locations: []string{"FIELD_DEFINITION", "OBJECT"}},
+		},
+	}
+}
+
+func TestIntrospect(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&req))
+		is.True(strings.Contains(req.Query, "__schema"))
+		is.NoErr(json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"__schema": testSchema()},
+		}))
+	}))
+	defer srv.Close()
+
+	schema, err := NewClient(srv.URL).Introspect(context.Background())
+	is.NoErr(err)
+	is.Equal(schema, testSchema())
+	is.Equal(schema.Type("Query").Field("items").Type.String(), "[Item!]!")
+	is.Equal(schema.Type("Query").Field("items").Type.NamedType(), "Item")
+}
+
+func TestSchemaSDL(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(testSchema().SDL(), `directive @cached(ttl: Int) on FIELD_DEFINITION | OBJECT
+
+"""
+Something for sale.
+"""
+type Item implements Node {
+  id: ID!
+  name: String
+  status: Status
+  updated: Time @deprecated(reason: "Use updatedAt.")
+}
+
+input ItemInput {
+  id: ID!
+  name: String
+}
+
+type Mutation {
+  updateItem(input: ItemInput!): Item
+}
+
+interface Node {
+  id: ID!
+}
+
+type Query {
+  item(id: ID!): Item
+  items(first: Int = 10): [Item!]!
+  search(text: String!): [SearchResult]
+}
+
+union SearchResult = Item | User
+
+enum Status {
+  ACTIVE
+  SOLD
+}
+
+"""
+An RFC 3339 time.
+"""
+scalar Time
+
+type User {
+  login: String
+}
+`)
+}
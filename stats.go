@@ -0,0 +1,115 @@
+package graphql
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyWindow is the number of recent requests Stats reports latency
+// percentiles over.
+const latencyWindow = 1024
+
+// Stats is a snapshot of the activity of a Client.
+type Stats struct {
+	// Requests is the number of requests run.
+	Requests int64
+	// TransportFailures counts requests that failed without a response
+	// from the server, such as connection errors and timeouts.
+	TransportFailures int64
+	// HTTPFailures counts requests that got a response that couldn't be
+	// decoded, such as an error status with a non-JSON body.
+	HTTPFailures int64
+	// GraphQLFailures counts requests where the server returned GraphQL
+	// errors.
+	GraphQLFailures int64
+	// Retries counts the retries made by the client and its queues.
+	Retries int64
+	// BytesSent and BytesReceived count request and response body bytes.
+	BytesSent     int64
+	BytesReceived int64
+	// LatencyP50, LatencyP90 and LatencyP99 are latency percentiles over
+	// the most recent requests.
+	LatencyP50 time.Duration
+	LatencyP90 time.Duration
+	LatencyP99 time.Duration
+}
+
+// clientStats collects the counters reported by Client.Stats.
+type clientStats struct {
+	requests          atomic.Int64
+	transportFailures atomic.Int64
+	httpFailures      atomic.Int64
+	graphqlFailures   atomic.Int64
+	retries           atomic.Int64
+	bytesSent         atomic.Int64
+	bytesReceived     atomic.Int64
+
+	mu        sync.Mutex
+	latencies [latencyWindow]time.Duration
+	next      int
+	full      bool
+}
+
+// record notes the outcome of a request.
+func (s *clientStats) record(res *http.Response, err error, latency time.Duration) {
+	s.requests.Add(1)
+	switch {
+	case err == nil:
+	case isResponseError(err):
+		s.graphqlFailures.Add(1)
+	case res == nil:
+		s.transportFailures.Add(1)
+	default:
+		s.httpFailures.Add(1)
+	}
+	s.mu.Lock()
+	s.latencies[s.next] = latency
+	s.next = (s.next + 1) % latencyWindow
+	if s.next == 0 {
+		s.full = true
+	}
+	s.mu.Unlock()
+}
+
+func isResponseError(err error) bool {
+	var responseErr *ResponseError
+	return errors.As(err, &responseErr)
+}
+
+// Stats returns a snapshot of the counters the client keeps about the
+// requests it has run.
+func (c *Client) Stats() Stats {
+	s := c.stats
+	stats := Stats{
+		Requests:          s.requests.Load(),
+		TransportFailures: s.transportFailures.Load(),
+		HTTPFailures:      s.httpFailures.Load(),
+		GraphQLFailures:   s.graphqlFailures.Load(),
+		Retries:           s.retries.Load(),
+		BytesSent:         s.bytesSent.Load(),
+		BytesReceived:     s.bytesReceived.Load(),
+	}
+	s.mu.Lock()
+	n := s.next
+	if s.full {
+		n = latencyWindow
+	}
+	latencies := make([]time.Duration, n)
+	copy(latencies, s.latencies[:n])
+	s.mu.Unlock()
+	if n == 0 {
+		return stats
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p int) time.Duration {
+		return latencies[(n-1)*p/100]
+	}
+	stats.LatencyP50 = percentile(50)
+	stats.LatencyP90 = percentile(90)
+	stats.LatencyP99 = percentile(99)
+	return stats
+}
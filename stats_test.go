@@ -0,0 +1,48 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestStats(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Fail") {
+		case "graphql":
+			io.WriteString(w, `{"errors":[{"message":"nope"}]}`)
+		case "http":
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, `Bad Gateway`)
+		default:
+			io.WriteString(w, `{"data":{}}`)
+		}
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL)
+	for _, fail := range []string{"", "", "graphql", "http"} {
+		client.Run(ctx, NewRequest("query {}").WithHeader("X-Fail", fail), nil)
+	}
+	client.endpoint = "http://127.0.0.1:0"
+	client.Run(ctx, NewRequest("query {}"), nil)
+
+	stats := client.Stats()
+	is.Equal(stats.Requests, int64(5))
+	is.Equal(stats.GraphQLFailures, int64(1))
+	is.Equal(stats.HTTPFailures, int64(1))
+	is.Equal(stats.TransportFailures, int64(1))
+	is.Equal(stats.BytesSent, int64(5*len(`{"query":"query {}","variables":null}`+"\n")))
+	is.True(stats.BytesReceived > 0)
+	is.True(stats.LatencyP50 > 0)
+	is.True(stats.LatencyP50 <= stats.LatencyP99)
+}
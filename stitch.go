@@ -0,0 +1,253 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Stitcher runs queries whose root fields are served by different
+// GraphQL endpoints, as a minimal gateway for internal tooling. Each
+// query is split up by root field, the parts are run at the same time
+// on the client for each field's endpoint, and their results are merged
+// back together.
+//
+//	stitcher := graphql.NewStitcher(map[string]*graphql.Client{
+//	    "products": catalog,
+//	    "orders":   orders,
+//	}, nil)
+//	err := stitcher.Run(ctx, graphql.NewRequest(`{ products { id } orders { id } }`), &resp)
+type Stitcher struct {
+	routes   map[string]*Client
+	fallback *Client
+}
+
+// NewStitcher makes a Stitcher that sends each root field named in routes
+// to its client. Fields that aren't in routes are sent to fallback, or
+// are an error if it is nil.
+func NewStitcher(routes map[string]*Client, fallback *Client) *Stitcher {
+	return &Stitcher{routes: routes, fallback: fallback}
+}
+
+// stitchPart is the part of a query sent to one endpoint.
+type stitchPart struct {
+	client *Client
+	fields [][]token
+	data   map[string]json.RawMessage
+	err    error
+}
+
+// Run runs the query, splitting it across endpoints, and unmarshals the
+// merged data into resp, as Client.Run would. GraphQL errors from every
+// endpoint are returned together in a ResponseError. If any part fails
+// outright its error is returned and resp is left alone.
+//
+// The request must contain a single operation without fragment spreads
+// at the root of its selection set. Only queries can be split up: a
+// mutation's fields must all go to the same endpoint, since mutations
+// are run in order.
+func (s *Stitcher) Run(ctx context.Context, req *Request, resp interface{}) error {
+	req = req.snapshot()
+	doc, err := parseDocument(req.q)
+	if err != nil {
+		return err
+	}
+	if len(doc.operations) != 1 {
+		return errors.New("graphql: stitch: request must contain exactly one operation")
+	}
+	op := doc.operations[0]
+	starts, err := doc.rootFields(op.selStart, op.selEnd)
+	if err != nil {
+		return err
+	}
+	var (
+		parts  []*stitchPart
+		byHost = make(map[*Client]*stitchPart)
+	)
+	for k, start := range starts {
+		end := op.selEnd - 1
+		if k+1 < len(starts) {
+			end = starts[k+1]
+		}
+		client, err := s.route(doc.tokens, start)
+		if err != nil {
+			return err
+		}
+		part := byHost[client]
+		if part == nil {
+			part = &stitchPart{client: client}
+			byHost[client] = part
+			parts = append(parts, part)
+		}
+		part.fields = append(part.fields, doc.tokens[start:end])
+	}
+	if len(parts) == 0 {
+		return errors.New("graphql: stitch: request selects no fields")
+	}
+	if len(parts) == 1 {
+		_, err := parts[0].client.Run(ctx, req, resp)
+		return err
+	}
+	if op.typ != "query" {
+		return fmt.Errorf("graphql: stitch: a %s can only be sent to one endpoint", op.typ)
+	}
+	if len(req.files) > 0 || len(req.formFields) > 0 {
+		return errors.New("graphql: stitch: cannot split requests with files or form fields")
+	}
+	var wg sync.WaitGroup
+	for _, part := range parts {
+		wg.Add(1)
+		go func(part *stitchPart) {
+			defer wg.Done()
+			_, part.err = part.client.Run(ctx, stitchRequest(req, doc, op, part.fields), &part.data)
+		}(part)
+	}
+	wg.Wait()
+
+	data := make(map[string]json.RawMessage)
+	var gqlErrs []GraphQLError
+	for _, part := range parts {
+		var responseErr *ResponseError
+		if part.err != nil && !errors.As(part.err, &responseErr) {
+			return part.err
+		}
+		if responseErr != nil {
+			gqlErrs = append(gqlErrs, responseErr.Errors...)
+		}
+		for key, value := range part.data {
+			data[key] = value
+		}
+	}
+	if resp != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return errors.Wrap(err, "encode stitched data")
+		}
+		if err := parts[0].client.decodeData(b, resp); err != nil {
+			return errors.Wrap(err, "decoding response")
+		}
+	}
+	if len(gqlErrs) > 0 {
+		return &ResponseError{Errors: gqlErrs}
+	}
+	return nil
+}
+
+// route returns the client for the root field starting at tokens[start].
+func (s *Stitcher) route(tokens []token, start int) (*Client, error) {
+	name := tokens[start].text
+	if tokens[start+1].is(":") {
+		name = tokens[start+2].text
+	}
+	if client, ok := s.routes[name]; ok {
+		return client, nil
+	}
+	if s.fallback != nil {
+		return s.fallback, nil
+	}
+	return nil, fmt.Errorf("graphql: stitch: no endpoint for field %q", name)
+}
+
+// stitchRequest builds the request for the given root fields of op,
+// keeping only the variables and fragments they use. Everything else
+// set on req, other than its files, carries over.
+func stitchRequest(req *Request, doc *document, op *operationDef, fields [][]token) *Request {
+	fragments := make(map[string]*fragmentDef, len(doc.fragments))
+	for _, frag := range doc.fragments {
+		fragments[frag.name] = frag
+	}
+	var selections []token
+	for _, field := range fields {
+		selections = append(selections, field...)
+	}
+	// find the fragments used, and those they use in turn
+	usedFragments := make(map[string]bool)
+	pending := [][]token{selections}
+	var fragmentTokens []token
+	for len(pending) > 0 {
+		tokens := pending[0]
+		pending = pending[1:]
+		for i := 0; i+1 < len(tokens); i++ {
+			if !tokens[i].is("...") || tokens[i+1].kind != tokenName || tokens[i+1].text == "on" {
+				continue
+			}
+			name := tokens[i+1].text
+			if frag, ok := fragments[name]; ok && !usedFragments[name] {
+				usedFragments[name] = true
+				fragTokens := doc.tokens[frag.start:frag.end]
+				fragmentTokens = append(fragmentTokens, fragTokens...)
+				pending = append(pending, fragTokens)
+			}
+		}
+	}
+	usedVars := make(map[string]bool)
+	for _, tokens := range [][]token{selections, fragmentTokens} {
+		for i := 0; i+1 < len(tokens); i++ {
+			if tokens[i].is("$") {
+				usedVars[tokens[i+1].text] = true
+			}
+		}
+	}
+
+	query := []token{{kind: tokenName, text: op.typ}}
+	directivesStart := op.selStart
+	if op.start != op.selStart {
+		directivesStart = op.start + 1
+		if op.name != "" {
+			query = append(query, doc.tokens[op.start+1])
+			directivesStart++
+		}
+	}
+	if op.varsEnd > 0 {
+		var defs []token
+		for _, def := range splitVariableDefinitions(doc.tokens[op.varsStart:op.varsEnd]) {
+			if usedVars[def[1].text] {
+				defs = append(defs, def...)
+			}
+		}
+		if len(defs) > 0 {
+			query = append(query, token{kind: tokenPunct, text: "("})
+			query = append(query, defs...)
+			query = append(query, token{kind: tokenPunct, text: ")"})
+		}
+		directivesStart = op.varsEnd + 1
+	}
+	query = append(query, doc.tokens[directivesStart:op.selStart]...)
+	query = append(query, token{kind: tokenPunct, text: "{"})
+	query = append(query, selections...)
+	query = append(query, token{kind: tokenPunct, text: "}"})
+	query = append(query, fragmentTokens...)
+
+	part := req.snapshot()
+	part.q = joinTokens(query)
+	for key := range part.vars {
+		if !usedVars[key] {
+			delete(part.vars, key)
+		}
+	}
+	return part
+}
+
+// splitVariableDefinitions splits the tokens of an operation's variable
+// definitions into a slice for each variable, starting with its $.
+func splitVariableDefinitions(tokens []token) [][]token {
+	var defs [][]token
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.is("(") || t.is("[") || t.is("{"):
+			depth++
+		case t.is(")") || t.is("]") || t.is("}"):
+			depth--
+		case depth == 0 && t.is("$") && i+1 < len(tokens):
+			defs = append(defs, nil)
+		}
+		if len(defs) > 0 {
+			defs[len(defs)-1] = append(defs[len(defs)-1], t)
+		}
+	}
+	return defs
+}
@@ -0,0 +1,142 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestStitcher(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		mu      sync.Mutex
+		queries = make(map[string]string)
+		vars    = make(map[string]string)
+	)
+	endpoint := func(name, data string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Query     string
+				Variables json.RawMessage
+			}
+			is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+			mu.Lock()
+			queries[name] = body.Query
+			vars[name] = string(body.Variables)
+			mu.Unlock()
+			io.WriteString(w, data)
+		}))
+	}
+	products := endpoint("products", `{"data":{"top":[{"id":"1","name":"Table"}]}}`)
+	defer products.Close()
+	orders := endpoint("orders", `{"data":{"orders":[{"id":"9"}]},"errors":[{"message":"partial"}]}`)
+	defer orders.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	stitcher := NewStitcher(map[string]*Client{
+		"products": NewClient(products.URL),
+		"orders":   NewClient(orders.URL),
+	}, nil)
+	req := NewRequest(`query Home($first: Int, $user: ID!) {
+		top: products(first: $first) { ...product }
+		orders(user: $user) { id }
+	}
+	fragment product on Product { id name }`)
+	req.Var("first", 1)
+	req.Var("user", "u1")
+	var resp struct {
+		Top []struct {
+			ID   string
+			Name string
+		}
+		Orders []struct {
+			ID string
+		}
+	}
+	err := stitcher.Run(ctx, req, &resp)
+	is.Equal(err.Error(), "graphql: partial")
+	is.Equal(len(resp.Top), 1)
+	is.Equal(resp.Top[0].Name, "Table")
+	is.Equal(len(resp.Orders), 1)
+	is.Equal(resp.Orders[0].ID, "9")
+
+	is.Equal(queries["products"], "query Home($first: Int) { top: products(first: $first) { ...product } } fragment product on Product { id name }")
+	is.Equal(vars["products"], `{"first":1}`)
+	is.Equal(queries["orders"], "query Home($user: ID!) { orders(user: $user) { id } }")
+	is.Equal(vars["orders"], `{"user":"u1"}`)
+
+	err = stitcher.Run(ctx, NewRequest("{ products { id } reviews { id } }"), nil)
+	is.Equal(err.Error(), `graphql: stitch: no endpoint for field "reviews"`)
+	err = stitcher.Run(ctx, NewRequest("mutation { products { id } orders { id } }"), nil)
+	is.Equal(err.Error(), "graphql: stitch: a mutation can only be sent to one endpoint")
+}
+
+func TestStitcherNoFields(t *testing.T) {
+	is := is.New(t)
+
+	stitcher := NewStitcher(nil, NewClient("http://api.test/graphql"))
+	err := stitcher.Run(context.Background(), NewRequest("{}"), nil)
+	is.Equal(err.Error(), "graphql: stitch: request selects no fields")
+}
+
+func TestStitcherKeepsRequestSettings(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		mu         sync.Mutex
+		operations = make(map[string]string)
+		signed     = make(map[string]string)
+	)
+	endpoint := func(name string, delay time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				OperationName string
+			}
+			is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+			mu.Lock()
+			operations[name] = body.OperationName
+			signed[name] = r.Header.Get("X-Signed")
+			mu.Unlock()
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+			io.WriteString(w, `{"data":{}}`)
+		}))
+	}
+	products := endpoint("products", 0)
+	defer products.Close()
+	orders := endpoint("orders", time.Second)
+	defer orders.Close()
+
+	stitcher := NewStitcher(map[string]*Client{
+		"products": NewClient(products.URL),
+		"orders":   NewClient(orders.URL),
+	}, nil)
+	req := NewRequest("{ products { id } orders { id } }")
+	req.Operation("Home")
+	req.Use(func(next Runner) Runner {
+		return RunnerFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			req.Header.Set("X-Signed", "yes")
+			return next.Execute(ctx, req)
+		})
+	})
+	req.WithTimeout(50 * time.Millisecond)
+	err := stitcher.Run(context.Background(), req, nil)
+	is.True(errors.Is(err, context.DeadlineExceeded)) // the slow part timed out
+	mu.Lock()
+	defer mu.Unlock()
+	is.Equal(operations, map[string]string{"products": "Home", "orders": "Home"})
+	is.Equal(signed, map[string]string{"products": "yes", "orders": "yes"})
+}
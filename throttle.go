@@ -0,0 +1,115 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ThrottledError is returned when the server refused a request because
+// the client has sent too much, either with a 429 status or with an
+// error whose extensions code is THROTTLED or RATE_LIMITED. Its Err is
+// the HTTPError or ResponseError the server's response amounts to, so
+// errors.As finds those too.
+type ThrottledError struct {
+	// RetryAfter is how long to wait before trying again, or zero if the
+	// server gave no way to tell. It is taken from the Retry-After
+	// header, or else worked out from the cost extension or the rate
+	// limit headers.
+	RetryAfter time.Duration
+	// Cost is the cost the server reported, if any.
+	Cost *Cost
+	// RateLimit is the rate limit the server reported, if any.
+	RateLimit *RateLimit
+	Err       error
+}
+
+func (e *ThrottledError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("graphql: throttled, retry after %s: %v", e.RetryAfter, e.Err)
+	}
+	return fmt.Sprintf("graphql: throttled: %v", e.Err)
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return e.Err
+}
+
+// throttled returns err as a ThrottledError if res, decoded into gr,
+// says the request was throttled, and err unchanged otherwise.
+func (c *Client) throttled(res *http.Response, gr *graphResponse, err error) error {
+	if res == nil || (res.StatusCode != http.StatusTooManyRequests && !throttleCode(gr.Errors)) {
+		return err
+	}
+	e := &ThrottledError{RateLimit: c.parseRateLimit(res), Err: err}
+	if c.costParser != nil {
+		if cost, ok := c.costParser(c.responseExtensions(gr.body)); ok {
+			cost.Throttled = true
+			e.Cost = &cost
+		}
+	}
+	now := time.Now()
+	switch after, ok := parseRetryAfter(res.Header.Get("Retry-After"), now); {
+	case ok:
+		e.RetryAfter = after
+	case e.Cost != nil:
+		e.RetryAfter = e.Cost.Wait(e.Cost.Requested)
+	case e.RateLimit != nil && e.RateLimit.Remaining == 0 && e.RateLimit.Reset.After(now):
+		e.RetryAfter = e.RateLimit.Reset.Sub(now)
+	}
+	return e
+}
+
+// throttleCode reports whether any of errs has a code saying the request
+// was throttled.
+func throttleCode(errs []GraphQLError) bool {
+	for _, err := range errs {
+		switch code, _ := err.Extensions["code"].(string); code {
+		case "THROTTLED", "RATE_LIMITED":
+			return true
+		}
+	}
+	return false
+}
+
+// responseExtensions decodes the extensions of the response body.
+func (c *Client) responseExtensions(body []byte) map[string]interface{} {
+	var envelope struct {
+		Extensions map[string]interface{}
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if c.useNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(&envelope); err != nil {
+		return nil
+	}
+	return envelope.Extensions
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number
+// of seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if t.Before(now) {
+		return 0, true
+	}
+	return t.Sub(now), true
+}
@@ -0,0 +1,122 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestThrottledError(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		after   time.Duration
+	}{
+		{
+			name: "retry after",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "3")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			after: 3 * time.Second,
+		},
+		{
+			name: "cost",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, `{"errors":[{"message":"Throttled","extensions":{"code":"THROTTLED"}}],
+					"extensions":{"cost":{"requestedQueryCost":200,
+					"throttleStatus":{"maximumAvailable":1000,"currentlyAvailable":100,"restoreRate":50}}}}`)
+			},
+			after: 2 * time.Second,
+		},
+		{
+			name: "no hint",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, `{"errors":[{"message":"slow down","extensions":{"code":"RATE_LIMITED"}}]}`)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			_, err := NewClient(srv.URL).Run(context.Background(), NewRequest("query { item }"), nil)
+			var throttledErr *ThrottledError
+			is.True(errors.As(err, &throttledErr))
+			is.Equal(throttledErr.RetryAfter, tt.after)
+		})
+	}
+}
+
+func TestThrottledErrorRateLimit(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "10")
+		w.Header().Set("RateLimit-Remaining", "0")
+		w.Header().Set("RateLimit-Reset", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"errors":[{"message":"too many requests"}]}`)
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(srv.URL).Run(context.Background(), NewRequest("query { item }"), nil)
+	var throttledErr *ThrottledError
+	is.True(errors.As(err, &throttledErr))
+	is.True(throttledErr.RetryAfter > 55*time.Second && throttledErr.RetryAfter <= 60*time.Second)
+	is.Equal(throttledErr.RateLimit.Limit, int64(10))
+	var responseErr *ResponseError
+	is.True(errors.As(err, &responseErr))
+	is.Equal(responseErr.Errors[0].Message, "too many requests")
+}
+
+func TestRetryWaitsForThrottle(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, `{"data":{"item":"some data"}}`)
+	}))
+	defer srv.Close()
+
+	var delays []time.Duration
+	client := NewClient(srv.URL, WithRetry(2, func(int) time.Duration { return time.Millisecond }),
+		WithEventListener(delayRecorder{delays: &delays}))
+	_, err := client.Run(context.Background(), NewRequest("query { item }"), nil)
+	is.NoErr(err)
+	is.Equal(calls, 2)
+	is.Equal(delays, []time.Duration{time.Second})
+}
+
+type delayRecorder struct {
+	NopEventListener
+	delays *[]time.Duration
+}
+
+func (r delayRecorder) RetryScheduled(e RetryScheduledEvent) {
+	*r.delays = append(*r.delays, e.Delay)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	is := is.New(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	after, ok := parseRetryAfter("Thu, 01 Jan 2026 00:00:30 GMT", now)
+	is.True(ok)
+	is.Equal(after, 30*time.Second)
+	_, ok = parseRetryAfter("soon", now)
+	is.True(!ok)
+	_, ok = parseRetryAfter("-1", now)
+	is.True(!ok)
+}
@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeFormat converts a time.Time variable to the value sent for it,
+// for servers whose scalars expect something other than the RFC 3339
+// string with nanoseconds that encoding/json produces.
+type TimeFormat func(t time.Time) interface{}
+
+// DurationFormat converts a time.Duration variable to the value sent for
+// it, rather than the number of nanoseconds encoding/json produces.
+type DurationFormat func(d time.Duration) interface{}
+
+// TimeRFC3339 formats times as RFC 3339 strings to the second.
+func TimeRFC3339(t time.Time) interface{} {
+	return t.Format(time.RFC3339)
+}
+
+// TimeRFC3339Nano formats times as RFC 3339 strings with as many
+// fractional digits as they need.
+func TimeRFC3339Nano(t time.Time) interface{} {
+	return t.Format(time.RFC3339Nano)
+}
+
+// TimeUnixMillis sends times as the number of milliseconds since the
+// Unix epoch.
+func TimeUnixMillis(t time.Time) interface{} {
+	return t.UnixMilli()
+}
+
+// DurationMillis sends durations as a whole number of milliseconds.
+func DurationMillis(d time.Duration) interface{} {
+	return d.Milliseconds()
+}
+
+// DurationISO8601 formats durations as ISO 8601 durations, such as
+// PT1H30M or PT0.5S.
+func DurationISO8601(d time.Duration) interface{} {
+	if d == 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteString("PT")
+	if h := d / time.Hour; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+		d -= m * time.Minute
+	}
+	if d > 0 {
+		secs, nanos := d/time.Second, d%time.Second
+		if nanos == 0 {
+			fmt.Fprintf(&b, "%dS", secs)
+		} else {
+			fraction := strings.TrimRight(fmt.Sprintf("%09d", nanos), "0")
+			fmt.Fprintf(&b, "%d.%sS", secs, fraction)
+		}
+	}
+	return b.String()
+}
+
+// WithTimeFormat sets how time.Time variables are sent, such as
+// graphql.TimeUnixMillis. Request.TimeFormat overrides it for a
+// particular request.
+//
+// Formats apply to variables that are times, or pointers to them, and
+// to times within maps of type map[string]interface{} and slices of
+// type []interface{}. Times within structs are encoded as they would be
+// with encoding/json.
+func WithTimeFormat(format TimeFormat) ClientOption {
+	return func(client *Client) {
+		client.timeFormat = format
+	}
+}
+
+// WithDurationFormat sets how time.Duration variables are sent, such as
+// graphql.DurationISO8601, in the same way WithTimeFormat does for
+// times. Request.DurationFormat overrides it for a particular request.
+func WithDurationFormat(format DurationFormat) ClientOption {
+	return func(client *Client) {
+		client.durationFormat = format
+	}
+}
+
+// TimeFormat sets how the request's time.Time variables are sent,
+// overriding WithTimeFormat.
+func (req *Request) TimeFormat(format TimeFormat) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.timeFormat = format
+}
+
+// DurationFormat sets how the request's time.Duration variables are
+// sent, overriding WithDurationFormat.
+func (req *Request) DurationFormat(format DurationFormat) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.durationFormat = format
+}
@@ -0,0 +1,71 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestTimeFormats(t *testing.T) {
+	is := is.New(t)
+
+	ts := time.Date(2024, 3, 1, 12, 30, 15, 500000000, time.UTC)
+	is.Equal(TimeRFC3339(ts), "2024-03-01T12:30:15Z")
+	is.Equal(TimeRFC3339Nano(ts), "2024-03-01T12:30:15.5Z")
+	is.Equal(TimeUnixMillis(ts), ts.UnixMilli())
+	is.Equal(DurationMillis(1500*time.Millisecond), int64(1500))
+	for d, want := range map[time.Duration]string{
+		0:                                  "PT0S",
+		90 * time.Minute:                   "PT1H30M",
+		500 * time.Millisecond:             "PT0.5S",
+		26*time.Hour + 5*time.Second:       "PT26H5S",
+		-(time.Minute + 1*time.Nanosecond): "-PT1M0.000000001S",
+	} {
+		is.Equal(DurationISO8601(d), want)
+	}
+}
+
+func TestWithTimeFormat(t *testing.T) {
+	is := is.New(t)
+
+	var variables []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables json.RawMessage
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		variables = append(variables, string(body.Variables))
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	ts := time.Date(2024, 3, 1, 12, 30, 15, 500000000, time.UTC)
+	client := NewClient(srv.URL, WithTimeFormat(TimeUnixMillis), WithDurationFormat(DurationISO8601))
+	req := NewRequest("query ($at: Time, $ttl: Duration, $filter: Filter) { a }")
+	req.Var("at", ts)
+	req.Var("ttl", time.Minute)
+	req.Var("filter", map[string]interface{}{"since": &ts, "windows": []interface{}{time.Second}})
+	_, err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+
+	req.TimeFormat(TimeRFC3339)
+	_, err = client.Run(ctx, req, nil)
+	is.NoErr(err)
+
+	_, err = NewClient(srv.URL).Run(ctx, req.WithVar("filter", nil), nil)
+	is.NoErr(err)
+	is.Equal(variables, []string{
+		`{"at":1709296215500,"filter":{"since":1709296215500,"windows":["PT1S"]},"ttl":"PT1M"}`,
+		`{"at":"2024-03-01T12:30:15Z","filter":{"since":"2024-03-01T12:30:15Z","windows":["PT1S"]},"ttl":"PT1M"}`,
+		`{"at":"2024-03-01T12:30:15Z","filter":null,"ttl":60000000000}`, // the request's format, and encoding/json's for durations
+	})
+	is.Equal(req.Vars()["at"], ts) // the request's own variables are left alone
+}
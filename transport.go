@@ -0,0 +1,262 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The options in this file configure an http.Transport owned by the
+// client, saving users from building one by hand. They have no effect
+// on a Client given its own http.Client with WithHTTPClient.
+
+// ownTransport returns the transport owned by the client, creating it
+// from a copy of http.DefaultTransport the first time it's needed.
+// A transport inherited from the parent of a derived client is copied
+// before it is changed.
+func (c *Client) ownTransport() *http.Transport {
+	switch {
+	case c.transport == nil:
+		c.transport = http.DefaultTransport.(*http.Transport).Clone()
+	case c.transportShared:
+		c.transport = c.transport.Clone()
+		if c.dialer != nil {
+			dialer := *c.dialer
+			c.dialer = &dialer
+			c.transport.DialContext = c.dialer.DialContext
+		}
+	}
+	c.transportShared = false
+	return c.transport
+}
+
+// ownDialer returns the dialer used by the client owned transport,
+// starting with the same settings as http.DefaultTransport.
+func (c *Client) ownDialer() *net.Dialer {
+	c.ownTransport()
+	if c.dialer == nil {
+		c.dialer = &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+		c.ownTransport().DialContext = c.dialer.DialContext
+	}
+	return c.dialer
+}
+
+// useOwnTransport makes the client send requests with its own transport,
+// if one has been configured and no http.Client was given.
+func (c *Client) useOwnTransport() {
+	if c.transport != nil && (c.dial != nil || c.dnsCache != nil) {
+		dial := c.dial
+		if dial == nil {
+			dial = c.ownDialer().DialContext
+		}
+		if c.dnsCache != nil {
+			dial = c.dnsCache.wrap(dial)
+		}
+		c.transport.DialContext = dial
+	}
+	if c.httpClient == nil && c.transport != nil {
+		c.httpClient = &http.Client{Transport: c.transport}
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections
+// kept open to the server. The http.DefaultTransport default of 2 is
+// often too low for a busy client.
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(client *Client) {
+		client.ownTransport().MaxIdleConnsPerHost = n
+		if t := client.ownTransport(); t.MaxIdleConns != 0 && t.MaxIdleConns < n {
+			t.MaxIdleConns = n
+		}
+	}
+}
+
+// WithMaxConnsPerHost limits the total number of connections, idle or in
+// use, to the server. Zero means no limit.
+func WithMaxConnsPerHost(n int) ClientOption {
+	return func(client *Client) {
+		client.ownTransport().MaxConnsPerHost = n
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection is kept open
+// before it is closed.
+func WithIdleConnTimeout(d time.Duration) ClientOption {
+	return func(client *Client) {
+		client.ownTransport().IdleConnTimeout = d
+	}
+}
+
+// WithKeepAlive sets the interval between TCP keep-alive probes on the
+// connections to the server. A negative duration disables them.
+func WithKeepAlive(d time.Duration) ClientOption {
+	return func(client *Client) {
+		client.ownDialer().KeepAlive = d
+	}
+}
+
+// WithoutConnectionReuse disables HTTP keep-alives, so every request
+// opens a new connection.
+func WithoutConnectionReuse() ClientOption {
+	return func(client *Client) {
+		client.ownTransport().DisableKeepAlives = true
+	}
+}
+
+// WithDialContext sets the function used to open connections to the
+// server, for example to pin the endpoint to a particular address or to
+// look it up with service discovery.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption {
+	return func(client *Client) {
+		client.ownTransport()
+		client.dial = dial
+	}
+}
+
+// WithDNSCache caches the addresses the server's host name resolves to
+// for ttl, instead of looking them up for every new connection.
+func WithDNSCache(ttl time.Duration) ClientOption {
+	return func(client *Client) {
+		client.ownTransport()
+		client.dnsCache = &dnsCache{
+			ttl:      ttl,
+			resolver: net.DefaultResolver,
+			entries:  make(map[string]dnsEntry),
+		}
+	}
+}
+
+// dnsCache is a cache of host name lookups.
+type dnsCache struct {
+	ttl      time.Duration
+	resolver *net.Resolver
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// wrap returns a dial function that resolves host names using the
+// cache and then dials the resulting addresses with dial in turn.
+func (d *dnsCache) wrap(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+		addrs, err := d.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var conn net.Conn
+		for _, ip := range addrs {
+			conn, err = dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
+
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	d.mu.Lock()
+	entry, ok := d.entries[host]
+	d.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+	addrs, err := d.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	d.entries[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(d.ttl)}
+	d.mu.Unlock()
+	return addrs, nil
+}
+
+// WithDialTimeout limits how long opening a connection to the server may
+// take.
+func WithDialTimeout(d time.Duration) ClientOption {
+	return func(client *Client) {
+		client.ownDialer().Timeout = d
+	}
+}
+
+// WithTLSHandshakeTimeout limits how long the TLS handshake with the
+// server may take.
+func WithTLSHandshakeTimeout(d time.Duration) ClientOption {
+	return func(client *Client) {
+		client.ownTransport().TLSHandshakeTimeout = d
+	}
+}
+
+// WithResponseHeaderTimeout limits how long the client waits for the
+// server to start responding once the request has been sent.
+func WithResponseHeaderTimeout(d time.Duration) ClientOption {
+	return func(client *Client) {
+		client.ownTransport().ResponseHeaderTimeout = d
+	}
+}
+
+// Timeout phases reported by TimeoutError.
+const (
+	TimeoutDial           = "dial"
+	TimeoutTLSHandshake   = "TLS handshake"
+	TimeoutResponseHeader = "response header"
+	TimeoutRequest        = "request"
+)
+
+// TimeoutError is returned when a request times out. Phase says which
+// limit was hit, so slow connects can be told apart from slow servers:
+// one of TimeoutDial, TimeoutTLSHandshake, TimeoutResponseHeader, or
+// TimeoutRequest for the overall deadline.
+type TimeoutError struct {
+	Phase string
+	Err   error
+}
+
+func (e *TimeoutError) Error() string {
+	return "graphql: " + e.Phase + " timeout: " + e.Err.Error()
+}
+
+// Timeout is always true. It satisfies the net.Error convention.
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// classifyTimeout wraps err from sending a request in a TimeoutError if
+// it was caused by a timeout.
+func classifyTimeout(err error) error {
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		return err
+	}
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return &TimeoutError{Phase: TimeoutDial, Err: err}
+	case strings.Contains(err.Error(), "TLS handshake timeout"):
+		return &TimeoutError{Phase: TimeoutTLSHandshake, Err: err}
+	case strings.Contains(err.Error(), "timeout awaiting response headers"):
+		return &TimeoutError{Phase: TimeoutResponseHeader, Err: err}
+	default:
+		return &TimeoutError{Phase: TimeoutRequest, Err: err}
+	}
+}
@@ -0,0 +1,112 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestTransportOptions(t *testing.T) {
+	is := is.New(t)
+
+	client := NewClient("", WithMaxIdleConnsPerHost(64), WithIdleConnTimeout(time.Minute), WithKeepAlive(time.Second))
+	transport := client.httpClient.Transport.(*http.Transport)
+	is.Equal(transport.MaxIdleConnsPerHost, 64)
+	is.Equal(transport.IdleConnTimeout, time.Minute)
+	is.Equal(client.dialer.KeepAlive, time.Second)
+	is.True(transport != http.DefaultTransport)
+
+	derived := client.With(WithMaxConnsPerHost(8))
+	derivedTransport := derived.httpClient.Transport.(*http.Transport)
+	is.Equal(derivedTransport.MaxConnsPerHost, 8)
+	is.Equal(derivedTransport.MaxIdleConnsPerHost, 64) // inherited
+	is.Equal(transport.MaxConnsPerHost, 0)             // original is untouched
+	is.Equal(client.With().httpClient, client.httpClient)
+
+	custom := &http.Client{}
+	is.Equal(NewClient("", WithHTTPClient(custom), WithMaxIdleConnsPerHost(64)).httpClient, custom)
+	is.Equal(NewClient("").httpClient, http.DefaultClient)
+}
+
+func TestWithDialContext(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Host, "graphql.example.invalid")
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var dialed []string
+	client := NewClient("http://graphql.example.invalid/query",
+		WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			return net.Dial(network, srv.Listener.Addr().String())
+		}),
+		WithKeepAlive(time.Second),
+	)
+	_, err := client.Run(ctx, NewRequest("query {}"), nil)
+	is.NoErr(err)
+	is.Equal(dialed, []string{"graphql.example.invalid:80"})
+}
+
+func TestWithDNSCache(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	is.NoErr(err)
+	client := NewClient("http://localhost:"+port, WithDNSCache(time.Minute), WithoutConnectionReuse())
+	for i := 0; i < 2; i++ {
+		_, err = client.Run(ctx, NewRequest("query {}"), nil)
+		is.NoErr(err)
+	}
+	is.True(len(client.dnsCache.entries["localhost"].addrs) > 0)
+}
+
+func TestGranularTimeouts(t *testing.T) {
+	is := is.New(t)
+
+	client := NewClient("", WithDialTimeout(time.Second), WithTLSHandshakeTimeout(2*time.Second), WithResponseHeaderTimeout(3*time.Second))
+	transport := client.httpClient.Transport.(*http.Transport)
+	is.Equal(client.dialer.Timeout, time.Second)
+	is.Equal(transport.TLSHandshakeTimeout, 2*time.Second)
+	is.Equal(transport.ResponseHeaderTimeout, 3*time.Second)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client = NewClient(srv.URL, WithResponseHeaderTimeout(20*time.Millisecond))
+	_, err := client.Run(context.Background(), NewRequest("query {}"), nil)
+	var timeoutErr *TimeoutError
+	is.True(errors.As(err, &timeoutErr))
+	is.Equal(timeoutErr.Phase, TimeoutResponseHeader)
+
+	err = classifyTimeout(&net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded})
+	is.True(errors.As(err, &timeoutErr))
+	is.Equal(timeoutErr.Phase, TimeoutDial)
+	is.True(errors.Is(err, os.ErrDeadlineExceeded))
+
+	err = classifyTimeout(io.EOF)
+	is.Equal(err, io.EOF)
+}
@@ -0,0 +1,104 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// defaultBase64UploadLimit is the largest file sent as a variable
+// unless WithBase64Uploads says otherwise.
+const defaultBase64UploadLimit = 10 << 20
+
+// WithBase64Uploads sends files as variables holding their contents as
+// base64 strings, for servers that don't support the multipart request
+// spec, so the same Request.File calls work with either kind of server.
+// Each file is sent in the variable named by its Field, and the request
+// is sent as JSON, or as the transport chosen by WithTransport or
+// Request.Transport says, unless that is multipart.
+//
+// Files are read into memory to be encoded, so any larger than maxSize
+// bytes fail the request; zero or less means 10MB.
+func WithBase64Uploads(maxSize int64) ClientOption {
+	return func(client *Client) {
+		if maxSize <= 0 {
+			maxSize = defaultBase64UploadLimit
+		}
+		client.base64Uploads = maxSize
+	}
+}
+
+// filesToVariables moves the files of req, a snapshot, into its
+// variables as base64 strings.
+func (c *Client) filesToVariables(ctx context.Context, req *Request) error {
+	if req.vars == nil {
+		req.vars = make(map[string]interface{}, len(req.files))
+	}
+	for _, f := range req.files {
+		var contents bytes.Buffer
+		r := io.LimitReader(&contextReader{ctx: ctx, r: f.R}, c.base64Uploads+1)
+		if _, err := contents.ReadFrom(r); err != nil {
+			return errors.Wrap(err, "preparing file")
+		}
+		if int64(contents.Len()) > c.base64Uploads {
+			return fmt.Errorf("graphql: file %q is larger than the %d byte limit for base64 uploads", f.Name, c.base64Uploads)
+		}
+		req.vars[f.Field] = base64.StdEncoding.EncodeToString(contents.Bytes())
+	}
+	req.files = nil
+	return nil
+}
+
+// OnUploadAbort sets a function to call if the request's files are
+// abandoned because the context passed to Run is done before the
+// request finishes, such as to remove a temporary file. The file
+// readers that are io.Closers are closed first, so that a read blocked
+// on a pipe or network stream stops at once; fn is called with the
+// context's error.
+func (req *Request) OnUploadAbort(fn func(err error)) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.uploadAbort = fn
+}
+
+// abortUploadsWhenDone arranges for the request's file readers to be
+// closed, and its OnUploadAbort function called, if ctx is done before
+// the returned stop function is called.
+func (req *Request) abortUploadsWhenDone(ctx context.Context) (stop func()) {
+	req.mu.Lock()
+	files := append([]File(nil), req.files...)
+	abort := req.uploadAbort
+	req.mu.Unlock()
+	if len(files) == 0 {
+		return func() {}
+	}
+	stopAfter := context.AfterFunc(ctx, func() {
+		for _, f := range files {
+			if closer, ok := f.R.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+		if abort != nil {
+			abort(ctx.Err())
+		}
+	})
+	return func() { stopAfter() }
+}
+
+// contextReader stops reading from r once ctx is done, so that a
+// canceled upload doesn't go on draining its file.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestUploadAbort(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the upload should not have been sent")
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("first chunk"))
+		cancel()
+		// the rest never arrives, so reading blocks until the pipe is closed
+	}()
+	aborted := make(chan error, 1)
+	req := NewRequest(`mutation { upload }`)
+	req.File("file", "big.bin", pr)
+	req.OnUploadAbort(func(err error) {
+		aborted <- err
+	})
+	done := make(chan error)
+	go func() {
+		_, err := NewClient(srv.URL).Run(ctx, req, nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		is.True(err != nil)
+	case <-time.After(time.Second):
+		t.Fatal("Run kept reading the canceled upload")
+	}
+	is.Equal(<-aborted, context.Canceled)
+	_, err := pw.Write([]byte("more"))
+	is.Equal(err, io.ErrClosedPipe) // the reader was closed
+}
+
+func TestUploadNotAbortedAfterRun(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("contents"))
+		pw.Close()
+	}()
+	req := NewRequest(`mutation { upload }`)
+	req.File("file", "a.txt", pr)
+	req.OnUploadAbort(func(err error) {
+		t.Error("upload aborted after it finished")
+	})
+	_, err := NewClient(srv.URL).Run(ctx, req, nil)
+	is.NoErr(err)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestBase64Uploads(t *testing.T) {
+	is := is.New(t)
+
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("Content-Type"), "application/json; charset=utf-8")
+		b, err := io.ReadAll(r.Body)
+		is.NoErr(err)
+		bodies = append(bodies, string(b))
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(srv.URL, WithBase64Uploads(8))
+	req := NewRequest(`mutation ($avatar: String!) { upload(file: $avatar) }`)
+	req.Var("user", 1)
+	req.File("avatar", "a.png", strings.NewReader("png data"))
+	_, err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(bodies, []string{`{"query":"mutation ($avatar: String!) { upload(file: $avatar) }","variables":{"avatar":"cG5nIGRhdGE=","user":1}}` + "\n"})
+
+	req = NewRequest(`mutation ($avatar: String!) { upload(file: $avatar) }`)
+	req.File("avatar", "big.png", strings.NewReader("too much data"))
+	_, err = client.Run(ctx, req, nil)
+	is.Equal(err.Error(), `graphql: file "big.png" is larger than the 8 byte limit for base64 uploads`)
+	is.Equal(len(bodies), 1)
+}
@@ -0,0 +1,338 @@
+package graphql
+
+import (
+	"fmt"
+)
+
+// Validate checks the operations and fragments in the document q against
+// the schema, returning an error for each problem found: fields and
+// arguments the schema doesn't have, missing required arguments,
+// selection sets that are missing or not allowed, and unknown types.
+// It returns nil if the document is valid.
+//
+// Validate covers the mistakes most likely to creep in as a schema
+// changes; it is not a complete implementation of GraphQL validation.
+func (s *Schema) Validate(q string) []error {
+	doc, err := parseDocument(q)
+	if err != nil {
+		return []error{err}
+	}
+	v := &validator{schema: s, doc: doc, fragments: make(map[string]bool)}
+	for _, frag := range doc.fragments {
+		v.fragments[frag.name] = true
+	}
+	for _, op := range doc.operations {
+		v.operation(op)
+	}
+	for _, frag := range doc.fragments {
+		v.fragment(frag)
+	}
+	return v.errs
+}
+
+// validator walks a document checking it against a schema.
+type validator struct {
+	schema    *Schema
+	doc       *document
+	fragments map[string]bool
+	errs      []error
+}
+
+func (v *validator) errorf(t token, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("graphql: validation error at %d:%d: %s", t.line, t.col, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) operation(op *operationDef) {
+	var root *TypeRef
+	switch op.typ {
+	case "query":
+		root = v.schema.QueryType
+	case "mutation":
+		root = v.schema.MutationType
+	case "subscription":
+		root = v.schema.SubscriptionType
+	}
+	if root == nil {
+		v.errorf(v.doc.tokens[op.start], "schema does not support %ss", op.typ)
+		return
+	}
+	v.variables(op)
+	v.selectionSet(v.schema.Type(root.Name), op.selStart, op.selEnd)
+}
+
+// variables checks the types of the operation's variable definitions.
+func (v *validator) variables(op *operationDef) {
+	tokens := v.doc.tokens
+	depth := 0
+	for i := op.varsStart; i < op.varsEnd; i++ {
+		switch t := tokens[i]; {
+		case t.is("(") || t.is("[") || t.is("{"):
+			depth++
+		case t.is(")") || t.is("]") || t.is("}"):
+			depth--
+		case depth == 0 && t.is("$") && i+2 < op.varsEnd && tokens[i+2].is(":"):
+			j := i + 3
+			for j < op.varsEnd && tokens[j].is("[") {
+				j++
+			}
+			if j >= op.varsEnd || tokens[j].kind != tokenName {
+				continue
+			}
+			typ := v.schema.Type(tokens[j].text)
+			switch {
+			case typ == nil:
+				v.errorf(tokens[j], "unknown type %q", tokens[j].text)
+			case typ.Kind != "SCALAR" && typ.Kind != "ENUM" && typ.Kind != "INPUT_OBJECT":
+				v.errorf(tokens[j], "variable $%s cannot be of non-input type %q", tokens[i+1].text, typ.Name)
+			}
+			for j+1 < op.varsEnd && (tokens[j+1].is("]") || tokens[j+1].is("!")) {
+				j++
+			}
+			i = j
+		}
+	}
+}
+
+func (v *validator) fragment(frag *fragmentDef) {
+	tokens := v.doc.tokens
+	i := frag.start + 2
+	if i+1 >= frag.end || !tokens[i].isName("on") {
+		v.errorf(tokens[frag.start], "fragment %q has no type condition", frag.name)
+		return
+	}
+	typ := v.typeCondition(tokens[i+1])
+	if typ == nil {
+		return
+	}
+	start, err := v.doc.skipToSelectionSet(i + 2)
+	if err != nil {
+		v.errs = append(v.errs, err)
+		return
+	}
+	v.selectionSet(typ, start, frag.end)
+}
+
+// typeCondition returns the type named by t, reporting an error if it
+// doesn't exist or can't have fields selected on it.
+func (v *validator) typeCondition(t token) *SchemaType {
+	typ := v.schema.Type(t.text)
+	switch {
+	case typ == nil:
+		v.errorf(t, "unknown type %q", t.text)
+		return nil
+	case typ.Kind != "OBJECT" && typ.Kind != "INTERFACE" && typ.Kind != "UNION":
+		v.errorf(t, "fragment cannot condition on non composite type %q", typ.Name)
+		return nil
+	}
+	return typ
+}
+
+// selectionSet checks the selection set spanning tokens[start:end],
+// whose fields are selected on typ.
+func (v *validator) selectionSet(typ *SchemaType, start, end int) {
+	tokens := v.doc.tokens
+	// last is the closing brace, which nothing in the set may run past
+	last := end - 1
+	for i := start + 1; i < last; {
+		t := tokens[i]
+		if t.is("...") {
+			i++
+			var ok bool
+			switch {
+			case tokens[i].isName("on"):
+				if i+1 >= last || tokens[i+1].kind != tokenName {
+					v.syntaxError(tokens[i+1])
+					return
+				}
+				inner := v.typeCondition(tokens[i+1])
+				if i, ok = v.skipDirectives(i+2, last); !ok {
+					return
+				}
+				next, ok := v.subSelection(i, last)
+				if !ok {
+					return
+				}
+				if inner != nil {
+					v.selectionSet(inner, i, next)
+				}
+				i = next
+			case tokens[i].is("{") || tokens[i].is("@"):
+				if i, ok = v.skipDirectives(i, last); !ok {
+					return
+				}
+				next, ok := v.subSelection(i, last)
+				if !ok {
+					return
+				}
+				v.selectionSet(typ, i, next)
+				i = next
+			case i < last && tokens[i].kind == tokenName:
+				if !v.fragments[tokens[i].text] {
+					v.errorf(tokens[i], "unknown fragment %q", tokens[i].text)
+				}
+				if i, ok = v.skipDirectives(i+1, last); !ok {
+					return
+				}
+			default:
+				v.syntaxError(tokens[i])
+				return
+			}
+			continue
+		}
+		if t.kind != tokenName {
+			v.errorf(t, "unexpected %q", t.text)
+			return
+		}
+		nameToken := t
+		i++
+		if tokens[i].is(":") {
+			if i+1 >= last || tokens[i+1].kind != tokenName {
+				v.syntaxError(tokens[i+1])
+				return
+			}
+			nameToken = tokens[i+1]
+			i += 2
+		}
+		field := v.field(typ, nameToken)
+		if tokens[i].is("(") {
+			next, err := v.doc.skipBalanced(i)
+			if err != nil || next > last {
+				v.syntaxError(tokens[last])
+				return
+			}
+			if field != nil {
+				v.arguments(nameToken, field, i, next)
+			}
+			i = next
+		} else if field != nil {
+			v.arguments(nameToken, field, i, i)
+		}
+		var ok bool
+		if i, ok = v.skipDirectives(i, last); !ok {
+			return
+		}
+		var fieldType *SchemaType
+		if field != nil {
+			fieldType = v.schema.Type(field.Type.NamedType())
+		}
+		leaf := fieldType == nil || fieldType.Kind == "SCALAR" || fieldType.Kind == "ENUM"
+		if tokens[i].is("{") {
+			next, err := v.doc.skipBalanced(i)
+			if err != nil {
+				v.errs = append(v.errs, err)
+				return
+			}
+			if field != nil && leaf {
+				v.errorf(tokens[i], "field %q of type %q must not have a selection set", field.Name, field.Type.String())
+			} else if fieldType != nil {
+				v.selectionSet(fieldType, i, next)
+			}
+			i = next
+		} else if field != nil && !leaf {
+			v.errorf(nameToken, "field %q of type %q must have a selection set", field.Name, field.Type.String())
+		}
+	}
+}
+
+// subSelection returns the index just past the selection set that must
+// start at tokens[i], reporting an error if there isn't one before
+// tokens[last].
+func (v *validator) subSelection(i, last int) (int, bool) {
+	tokens := v.doc.tokens
+	if i >= last || !tokens[i].is("{") {
+		v.errorf(tokens[i], "expected selection set")
+		return 0, false
+	}
+	next, err := v.doc.skipBalanced(i)
+	if err != nil {
+		v.errs = append(v.errs, err)
+		return 0, false
+	}
+	return next, true
+}
+
+// syntaxError reports t as a token that can't appear where it is.
+func (v *validator) syntaxError(t token) {
+	v.errs = append(v.errs, v.doc.errorf(t, "unexpected %q", t.text))
+}
+
+// typenameField is the meta field every composite type has.
+var typenameField = &SchemaField{Name: "__typename", Type: TypeRef{Kind: "NON_NULL", OfType: &TypeRef{Kind: "SCALAR", Name: "String"}}}
+
+// field returns the field named by t on typ, reporting an error if there
+// is no such field.
+func (v *validator) field(typ *SchemaType, t token) *SchemaField {
+	if typ == nil {
+		return nil
+	}
+	if t.text == "__typename" {
+		return typenameField
+	}
+	if (t.text == "__schema" || t.text == "__type") && v.schema.QueryType != nil && typ.Name == v.schema.QueryType.Name {
+		// introspection fields aren't checked
+		return nil
+	}
+	field := typ.Field(t.text)
+	if field == nil {
+		v.errorf(t, "cannot query field %q on type %q", t.text, typ.Name)
+	}
+	return field
+}
+
+// arguments checks the arguments spanning tokens[start:end], including
+// the surrounding parentheses, given to field.
+func (v *validator) arguments(at token, field *SchemaField, start, end int) {
+	tokens := v.doc.tokens
+	given := make(map[string]bool)
+	depth := 0
+	for i := start; i < end; i++ {
+		switch t := tokens[i]; {
+		case t.is("(") || t.is("[") || t.is("{"):
+			depth++
+		case t.is(")") || t.is("]") || t.is("}"):
+			depth--
+		case depth == 1 && t.kind == tokenName && i+1 < end && tokens[i+1].is(":") && !tokens[i-1].is("$"):
+			given[t.text] = true
+			if !hasArg(field.Args, t.text) {
+				v.errorf(t, "unknown argument %q on field %q", t.text, field.Name)
+			}
+		}
+	}
+	for _, arg := range field.Args {
+		if arg.Type.Kind == "NON_NULL" && arg.DefaultValue == nil && !given[arg.Name] {
+			v.errorf(at, "field %q argument %q of type %q is required", field.Name, arg.Name, arg.Type.String())
+		}
+	}
+}
+
+func hasArg(args []SchemaInputValue, name string) bool {
+	for _, arg := range args {
+		if arg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// skipDirectives returns the index of the first token at or after
+// tokens[i] that isn't part of a directive, reporting an error if a
+// directive is malformed or runs into tokens[last].
+func (v *validator) skipDirectives(i, last int) (int, bool) {
+	tokens := v.doc.tokens
+	for i < last && tokens[i].is("@") {
+		if i+1 >= last || tokens[i+1].kind != tokenName {
+			v.syntaxError(tokens[i+1])
+			return i, false
+		}
+		i += 2
+		if i < last && tokens[i].is("(") {
+			next, err := v.doc.skipBalanced(i)
+			if err != nil || next > last {
+				v.syntaxError(tokens[last])
+				return i, false
+			}
+			i = next
+		}
+	}
+	return i, true
+}
@@ -0,0 +1,78 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	is := is.New(t)
+
+	schema := testSchema()
+	is.Equal(schema.Validate(`
+		query ($id: ID!, $ids: [ID!]!, $first: Int = 5) {
+			item(id: $id) { ...ItemFields status }
+			all: items(first: $first) @include(if: true) { id __typename }
+			search(text: "x") {
+				... on Item { name }
+				... on User { login }
+			}
+			__schema { types { name } }
+		}
+		mutation ($input: ItemInput!) {
+			updateItem(input: $input) { id }
+		}
+		fragment ItemFields on Item { id name }
+	`), []error(nil))
+
+	errs := schema.Validate(`query ($when: Item) {
+  item { nope id { deeper } }
+  items(last: 1)
+  search(text: "x") { login ... on Wat { id } ...Missing }
+}
+fragment F on Status { id }
+subscription { item }`)
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	is.Equal(messages, []string{
+		`graphql: validation error at 1:15: variable $when cannot be of non-input type "Item"`,
+		`graphql: validation error at 2:3: field "item" argument "id" of type "ID!" is required`,
+		`graphql: validation error at 2:10: cannot query field "nope" on type "Item"`,
+		`graphql: validation error at 2:18: field "id" of type "ID!" must not have a selection set`,
+		`graphql: validation error at 3:9: unknown argument "last" on field "items"`,
+		`graphql: validation error at 3:3: field "items" of type "[Item!]!" must have a selection set`,
+		`graphql: validation error at 4:23: cannot query field "login" on type "SearchResult"`,
+		`graphql: validation error at 4:36: unknown type "Wat"`,
+		`graphql: validation error at 4:50: unknown fragment "Missing"`,
+		`graphql: validation error at 7:1: schema does not support subscriptions`,
+		`graphql: validation error at 6:15: fragment cannot condition on non composite type "Status"`,
+	})
+
+	errs = schema.Validate("{ item(")
+	is.Equal(len(errs), 1) // syntax error
+}
+
+func TestSchemaValidateSyntaxErrors(t *testing.T) {
+	is := is.New(t)
+
+	schema := testSchema()
+	for _, test := range []struct {
+		q   string
+		err string
+	}{
+		{`{ a: }`, `graphql: syntax error at 1:6: unexpected "}"`},
+		{`{ ...on }`, `graphql: syntax error at 1:9: unexpected "}"`},
+		{`{ a @ }`, `graphql: syntax error at 1:7: unexpected "}"`},
+		{`{ ... }`, `graphql: syntax error at 1:7: unexpected "}"`},
+		{`{ item @include(if: true }`, `graphql: syntax error at 1:26: unexpected "}"`},
+		{`{ items(first: 1 }`, `graphql: syntax error at 1:18: unexpected "}"`},
+		{`{ ... on Item }`, `graphql: validation error at 1:15: expected selection set`},
+	} {
+		errs := schema.Validate(test.q)
+		is.True(len(errs) > 0)                        // test.q
+		is.Equal(errs[len(errs)-1].Error(), test.err) // test.q
+	}
+}
@@ -0,0 +1,138 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// prepareVariables returns the variables of req, a snapshot, as they are
+// to be sent: times and durations converted as the request or client
+// says, and enums checked and replaced by their names. The variables are
+// only copied if something in them changes.
+//
+// Values are looked at if they are variables themselves or are within
+// maps of type map[string]interface{} and slices of type []interface{};
+// values within structs are left to encoding/json.
+func (c *Client) prepareVariables(req *Request) (map[string]interface{}, error) {
+	w := variableWalker{time: c.timeFormat, duration: c.durationFormat}
+	if req.timeFormat != nil {
+		w.time = req.timeFormat
+	}
+	if req.durationFormat != nil {
+		w.duration = req.durationFormat
+	}
+	vars, _, err := w.walkMap(req.vars)
+	if err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// invalidEnumError reports an enum variable whose value isn't one the
+// enum allows. path locates it within the variables, such as
+// filter.statuses[1].
+type invalidEnumError struct {
+	path string
+	name string
+}
+
+func (e *invalidEnumError) Error() string {
+	return fmt.Sprintf("graphql: variable $%s: %q is not a value of the enum", e.path, e.name)
+}
+
+type variableWalker struct {
+	time     TimeFormat
+	duration DurationFormat
+}
+
+// walkMap returns m with its values converted, and whether any were.
+func (w variableWalker) walkMap(m map[string]interface{}) (map[string]interface{}, bool, *invalidEnumError) {
+	var converted map[string]interface{}
+	for key, value := range m {
+		v, changed, err := w.walk(value)
+		if err != nil {
+			if err.path != "" && err.path[0] != '[' {
+				key += "."
+			}
+			err.path = key + err.path
+			return nil, false, err
+		}
+		if !changed {
+			continue
+		}
+		if converted == nil {
+			converted = make(map[string]interface{}, len(m))
+			for key, value := range m {
+				converted[key] = value
+			}
+		}
+		converted[key] = v
+	}
+	if converted == nil {
+		return m, false, nil
+	}
+	return converted, true, nil
+}
+
+// walk returns value converted, and whether it was.
+func (w variableWalker) walk(value interface{}) (interface{}, bool, *invalidEnumError) {
+	switch v := value.(type) {
+	case GraphQLEnum:
+		name := v.EnumName()
+		if !isEnumValue(v, name) {
+			return nil, false, &invalidEnumError{name: name}
+		}
+		return name, true, nil
+	case time.Time:
+		if w.time != nil {
+			return w.time(v), true, nil
+		}
+	case *time.Time:
+		if w.time != nil && v != nil {
+			return w.time(*v), true, nil
+		}
+	case time.Duration:
+		if w.duration != nil {
+			return w.duration(v), true, nil
+		}
+	case map[string]interface{}:
+		return w.walkMap(v)
+	case Input:
+		return w.walkMap(v)
+	case []interface{}:
+		var converted []interface{}
+		for i, elem := range v {
+			e, changed, err := w.walk(elem)
+			if err != nil {
+				err.path = fmt.Sprintf("[%d]", i) + err.path
+				return nil, false, err
+			}
+			if !changed {
+				continue
+			}
+			if converted == nil {
+				converted = append([]interface{}(nil), v...)
+			}
+			converted[i] = e
+		}
+		if converted != nil {
+			return converted, true, nil
+		}
+	default:
+		if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice && rv.Type().Elem().Implements(enumType) {
+			// such as []Status, whose elements all need checking
+			names := make([]interface{}, rv.Len())
+			for i := range names {
+				name, _, err := w.walk(rv.Index(i).Interface())
+				if err != nil {
+					err.path = fmt.Sprintf("[%d]", i) + err.path
+					return nil, false, err
+				}
+				names[i] = name
+			}
+			return names, true, nil
+		}
+	}
+	return value, false, nil
+}
@@ -0,0 +1,150 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Warning is a notice from the server that something about a request,
+// such as a field or the endpoint itself, is deprecated or otherwise
+// ought to change, though the request succeeded.
+type Warning struct {
+	// Message describes the warning.
+	Message string
+	// Code is the machine readable code the server gave, if any.
+	Code string
+	// Path is the path to the field warned about, if any.
+	Path []interface{}
+	// Header names the response header the warning came from, or is
+	// empty if it came from the extensions of the response body.
+	Header string
+}
+
+// WithWarningHandler calls fn with each warning the server sends in
+// response to a request, so that deprecated usage shows up from
+// production traffic rather than when the server finally removes it.
+//
+// Warnings are read from the warnings and deprecations lists in the
+// response's extensions, whose entries may be strings or objects with a
+// message and optionally a code and path, and from the Warning and
+// Deprecation headers, along with any Sunset header. fn is called once
+// Run has its final response, on the goroutine running the request, so
+// it should return quickly.
+func WithWarningHandler(fn func(req *Request, w Warning)) ClientOption {
+	return func(client *Client) {
+		client.warningHandler = fn
+	}
+}
+
+// LogWarnings returns a handler for WithWarningHandler that logs each
+// warning with logf, such as log.Printf.
+func LogWarnings(logf func(format string, args ...interface{})) func(req *Request, w Warning) {
+	return func(req *Request, w Warning) {
+		source := "extensions"
+		if w.Header != "" {
+			source = w.Header + " header"
+		}
+		if len(w.Path) > 0 {
+			logf("graphql: warning from %s at %v: %s", source, w.Path, w.Message)
+			return
+		}
+		logf("graphql: warning from %s: %s", source, w.Message)
+	}
+}
+
+// reportWarnings passes the warnings in the response to the client's
+// warning handler.
+func (c *Client) reportWarnings(req *Request, res *http.Response, gr *graphResponse) {
+	if c.warningHandler == nil || res == nil {
+		return
+	}
+	for _, w := range headerWarnings(res.Header) {
+		c.warningHandler(req, w)
+	}
+	for _, w := range extensionWarnings(gr.body) {
+		c.warningHandler(req, w)
+	}
+}
+
+// headerWarnings returns the warnings given by the Warning and
+// Deprecation headers.
+func headerWarnings(h http.Header) []Warning {
+	var warnings []Warning
+	for _, value := range h.Values("Warning") {
+		warnings = append(warnings, Warning{Message: warningText(value), Header: "Warning"})
+	}
+	if deprecation := h.Get("Deprecation"); deprecation != "" {
+		message := "the endpoint is deprecated"
+		if deprecation != "true" {
+			message += " as of " + deprecation
+		}
+		if sunset := h.Get("Sunset"); sunset != "" {
+			message += " and will be removed at " + sunset
+		}
+		warnings = append(warnings, Warning{Message: message, Header: "Deprecation"})
+	}
+	return warnings
+}
+
+// warningText returns the text of a Warning header, which has the form
+// 299 agent "text" with an optional date after the text. Values not in
+// that form are returned whole.
+func warningText(value string) string {
+	start := strings.IndexByte(value, '"')
+	if start < 0 {
+		return value
+	}
+	var text strings.Builder
+	for i := start + 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if i+1 < len(value) {
+				i++
+				text.WriteByte(value[i])
+			}
+		case '"':
+			return text.String()
+		default:
+			text.WriteByte(value[i])
+		}
+	}
+	return value
+}
+
+// extensionWarnings returns the warnings in the extensions of the
+// response body.
+func extensionWarnings(body []byte) []Warning {
+	if !bytes.Contains(body, []byte(`"extensions"`)) {
+		return nil
+	}
+	var envelope struct {
+		Extensions struct {
+			Warnings     []json.RawMessage
+			Deprecations []json.RawMessage
+		}
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil
+	}
+	var warnings []Warning
+	for _, list := range [][]json.RawMessage{envelope.Extensions.Warnings, envelope.Extensions.Deprecations} {
+		for _, raw := range list {
+			var message string
+			if json.Unmarshal(raw, &message) == nil {
+				warnings = append(warnings, Warning{Message: message})
+				continue
+			}
+			var w Warning
+			if json.Unmarshal(raw, &struct {
+				Message *string
+				Code    *string
+				Path    *[]interface{}
+			}{&w.Message, &w.Code, &w.Path}) == nil && w.Message != "" {
+				warnings = append(warnings, w)
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,58 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWarningHandler(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Warning", `299 api.example.com "Use the v2 endpoint" "Wed, 01 Jan 2025 00:00:00 GMT"`)
+		w.Header().Set("Deprecation", "@1735689600")
+		w.Header().Set("Sunset", "Wed, 01 Jul 2026 00:00:00 GMT")
+		io.WriteString(w, `{"data":{"item":"some data"},"extensions":{
+			"warnings":["Rate limits change next week"],
+			"deprecations":[{"message":"Item.name is deprecated","code":"DEPRECATED_FIELD","path":["item","name"]}]
+		}}`)
+	}))
+	defer srv.Close()
+
+	var warnings []Warning
+	var logged []string
+	client := NewClient(srv.URL, WithWarningHandler(func(req *Request, w Warning) {
+		is.Equal(req.Query(), "query { item }")
+		warnings = append(warnings, w)
+		LogWarnings(func(format string, args ...interface{}) {
+			logged = append(logged, fmt.Sprintf(format, args...))
+		})(req, w)
+	}), WithResponseHeaders())
+	var resp struct {
+		Item string
+	}
+	_, err := client.Run(context.Background(), NewRequest("query { item }"), &resp)
+	is.NoErr(err)
+	is.Equal(resp.Item, "some data")
+	is.Equal(warnings, []Warning{
+		{Message: "Use the v2 endpoint", Header: "Warning"},
+		{Message: "the endpoint is deprecated as of @1735689600 and will be removed at Wed, 01 Jul 2026 00:00:00 GMT", Header: "Deprecation"},
+		{Message: "Rate limits change next week"},
+		{Message: "Item.name is deprecated", Code: "DEPRECATED_FIELD", Path: []interface{}{"item", "name"}},
+	})
+	is.Equal(logged[0], "graphql: warning from Warning header: Use the v2 endpoint")
+	is.Equal(logged[3], "graphql: warning from extensions at [item name]: Item.name is deprecated")
+}
+
+func TestWarningText(t *testing.T) {
+	is := is.New(t)
+	is.Equal(warningText(`299 - "Deprecated \"API\""`), `Deprecated "API"`)
+	is.Equal(warningText(`not in the usual form`), `not in the usual form`)
+	is.Equal(warningText(`299 - "unterminated`), `299 - "unterminated`)
+}